@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/gclhub/gh-secrets-manager/auth-server/pkg/auth"
 	"github.com/spf13/pflag"
@@ -18,6 +19,7 @@ func main() {
 		privateKeyPath = pflag.String("private-key-path", "", "Path to GitHub App private key PEM file")
 		organization   = pflag.String("organization", "", "GitHub organization name for team membership verification (optional - will be auto-detected from app installation if not provided)")
 		team           = pflag.String("team", "", "GitHub team name for membership verification")
+		githubAPIURL   = pflag.String("github-api-url", "", "GitHub API base URL, e.g. https://github.example.com/api/v3 for GitHub Enterprise Server (default https://api.github.com)")
 		verbose        = pflag.BoolP("verbose", "v", false, "Enable verbose logging")
 		help           = pflag.BoolP("help", "h", false, "Show help message")
 	)
@@ -44,6 +46,11 @@ func main() {
 		log.Println("Note: --organization not specified but --team is provided. Organization will be auto-detected from GitHub App installation.")
 	}
 
+	if *githubAPIURL != "" {
+		auth.SetGitHubAPIBaseURL(strings.TrimRight(*githubAPIURL, "/"))
+		log.Printf("Using GitHub API at %s", auth.GetGitHubAPIBaseURL())
+	}
+
 	log.Println("Starting GitHub App auth server...")
 	if *verbose {
 		log.Printf("Reading private key from: %s", *privateKeyPath)
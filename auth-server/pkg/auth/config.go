@@ -2,7 +2,7 @@ package auth
 
 var githubAPIBaseURL = "https://api.github.com"
 
-// SetGitHubAPIBaseURL allows overriding the GitHub API base URL for testing
+// SetGitHubAPIBaseURL overrides the GitHub API base URL, for GitHub Enterprise Server or testing
 func SetGitHubAPIBaseURL(url string) {
 	githubAPIBaseURL = url
 }
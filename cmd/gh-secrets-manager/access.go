@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/google/go-github/v45/github"
+	"github.com/spf13/cobra"
+)
+
+func addAccessFlags(cmd *cobra.Command) {
+	cmd.Flags().String("visibility", "", "Which organization repositories can use the entry: all, private or selected")
+	cmd.Flags().StringSlice("selected-repos", nil, "Comma-separated repository names that can use the entry (implies --visibility selected)")
+}
+
+// readAccess returns the access requested by --visibility and --selected-repos,
+// or nil when neither flag is set so existing access is left unchanged
+func readAccess(cmd *cobra.Command) (*plan.Access, error) {
+	visibility, _ := cmd.Flags().GetString("visibility")
+	repos, _ := cmd.Flags().GetStringSlice("selected-repos")
+
+	if len(repos) > 0 {
+		if visibility == "" {
+			visibility = api.VisibilitySelected
+		}
+		if visibility != api.VisibilitySelected {
+			return nil, fmt.Errorf("--selected-repos requires --visibility selected")
+		}
+	}
+
+	switch visibility {
+	case "":
+		return nil, nil
+	case api.VisibilityAll, api.VisibilityPrivate, api.VisibilitySelected:
+		return &plan.Access{Visibility: visibility, SelectedRepos: repos}, nil
+	}
+	return nil, fmt.Errorf("unsupported visibility: %s (expected all, private or selected)", visibility)
+}
+
+// withAccess sets the access of each change
+func withAccess(changes []plan.Change, access *plan.Access) []plan.Change {
+	for i := range changes {
+		changes[i].Access = access
+	}
+	return changes
+}
+
+// selectedRepoIDs looks up the IDs of the named repositories of an organization.
+// Names may also be given in owner/repo form, which is required when org is empty.
+func selectedRepoIDs(client *api.Client, org string, names []string) ([]int64, error) {
+	return newRepoIDCache(client).lookup(org, names)
+}
+
+// repoIDCache remembers the IDs of the repositories it looked up, so that the
+// changes of a plan, which usually share their selected repositories, look each one
+// up once. It is safe for concurrent use: the lock only guards the map, and workers
+// needing the same repository wait for one lookup rather than all lookups.
+type repoIDCache struct {
+	client *api.Client
+	mu     sync.Mutex
+	ids    map[string]*repoIDLookup
+}
+
+// repoIDLookup is the lookup of one repository's ID, done once
+type repoIDLookup struct {
+	once sync.Once
+	id   int64
+	err  error
+}
+
+func newRepoIDCache(client *api.Client) *repoIDCache {
+	return &repoIDCache{client: client, ids: make(map[string]*repoIDLookup)}
+}
+
+// lookup returns the IDs of the named repositories like selectedRepoIDs
+func (c *repoIDCache) lookup(org string, names []string) ([]int64, error) {
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		owner, repo := org, name
+		if o, r, ok := strings.Cut(name, "/"); ok {
+			owner, repo = o, r
+		}
+		if owner == "" {
+			return nil, fmt.Errorf("repository %s must be given as owner/repo", name)
+		}
+
+		key := owner + "/" + repo
+		c.mu.Lock()
+		entry, ok := c.ids[key]
+		if !ok {
+			entry = &repoIDLookup{}
+			c.ids[key] = entry
+		}
+		c.mu.Unlock()
+
+		entry.once.Do(func() {
+			repository, err := c.client.GetRepository(owner, repo)
+			entry.id, entry.err = repository.GetID(), err
+		})
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		ids = append(ids, entry.id)
+	}
+	return ids, nil
+}
+
+// selectedRepoEndpoints are the client calls that manage which repositories can use
+// an organization entry with selected visibility
+type selectedRepoEndpoints struct {
+	noun   string
+	list   func(client *api.Client, org, name string) ([]*github.Repository, error)
+	set    func(client *api.Client, org, name string, repoIDs []int64) error
+	add    func(client *api.Client, org, name string, repoID int64) error
+	remove func(client *api.Client, org, name string, repoID int64) error
+}
+
+// newReposCmd returns the repos command group managing the selected repositories of
+// an organization entry
+func newReposCmd(opts *api.ClientOptions, parent string, endpoints selectedRepoEndpoints) *cobra.Command {
+	reposCmd := &cobra.Command{
+		Use:   "repos",
+		Short: fmt.Sprintf("Manage the repositories that can use an organization %s", endpoints.noun),
+		Long: fmt.Sprintf(`Manage the repositories that can use an organization %[1]s whose visibility is selected.
+
+Usage:
+  # List the repositories that can use a %[1]s
+  $ gh secrets-manager %[2]s repos list --org myorg --name NAME
+
+  # Give more repositories access
+  $ gh secrets-manager %[2]s repos add --org myorg --name NAME --repos api,web
+
+  # Remove a repository's access
+  $ gh secrets-manager %[2]s repos remove --org myorg --name NAME --repos web
+
+  # Replace the list of repositories
+  $ gh secrets-manager %[2]s repos set --org myorg --name NAME --repos api,worker
+
+  # Remove every repository's access
+  $ gh secrets-manager %[2]s repos set --org myorg --name NAME --clear`, endpoints.noun, parent),
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: fmt.Sprintf("List the repositories that can use an organization %s", endpoints.noun),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, org, name, err := reposCommandArgs(cmd, opts)
+			if err != nil {
+				return err
+			}
+			repos, err := endpoints.list(client, org, name)
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(repos))
+			for _, repo := range repos {
+				names = append(names, repo.GetName())
+			}
+			return outputJSON(names)
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: fmt.Sprintf("Replace the repositories that can use an organization %s", endpoints.noun),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// An empty list removes every repository's access, so it has to be
+			// asked for with --clear rather than by leaving out --repos
+			repos, _ := cmd.Flags().GetStringSlice("repos")
+			clearRepos, _ := cmd.Flags().GetBool("clear")
+			switch {
+			case clearRepos && len(repos) > 0:
+				return fmt.Errorf("--clear cannot be combined with --repos")
+			case !clearRepos && len(repos) == 0:
+				return fmt.Errorf("--repos flag is required; use --clear to remove every repository")
+			}
+
+			client, org, name, err := reposCommandArgs(cmd, opts)
+			if err != nil {
+				return err
+			}
+			ids, err := selectedRepoIDs(client, org, repos)
+			if err != nil {
+				return err
+			}
+			return endpoints.set(client, org, name, ids)
+		},
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: fmt.Sprintf("Give repositories access to an organization %s", endpoints.noun),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateSelectedRepos(cmd, opts, endpoints.add)
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: fmt.Sprintf("Remove repositories' access to an organization %s", endpoints.noun),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateSelectedRepos(cmd, opts, endpoints.remove)
+		},
+	}
+
+	for _, command := range []*cobra.Command{listCmd, setCmd, addCmd, removeCmd} {
+		command.Flags().StringP("org", "o", "", "GitHub organization name")
+		command.Flags().String("name", "", fmt.Sprintf("Organization %s name", endpoints.noun))
+	}
+	for _, command := range []*cobra.Command{setCmd, addCmd, removeCmd} {
+		command.Flags().StringSlice("repos", nil, "Comma-separated repository names")
+	}
+
+	setCmd.Flags().Bool("clear", false, "Remove every repository, leaving none that can use the entry")
+
+	reposCmd.AddCommand(listCmd, addCmd, removeCmd, setCmd)
+	return reposCmd
+}
+
+func reposCommandArgs(cmd *cobra.Command, opts *api.ClientOptions) (*api.Client, string, string, error) {
+	org, _ := cmd.Flags().GetString("org")
+	name, _ := cmd.Flags().GetString("name")
+	if org == "" || name == "" {
+		return nil, "", "", fmt.Errorf("both --org and --name flags are required")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client, org, name, nil
+}
+
+func runUpdateSelectedRepos(cmd *cobra.Command, opts *api.ClientOptions, update func(*api.Client, string, string, int64) error) error {
+	repos, _ := cmd.Flags().GetStringSlice("repos")
+	if len(repos) == 0 {
+		return fmt.Errorf("--repos flag is required")
+	}
+
+	client, org, name, err := reposCommandArgs(cmd, opts)
+	if err != nil {
+		return err
+	}
+	ids, err := selectedRepoIDs(client, org, repos)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := update(client, org, name, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
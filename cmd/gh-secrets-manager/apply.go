@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/manifest"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/spf13/cobra"
+)
+
+func addApplyCommand(rootCmd *cobra.Command, opts *api.ClientOptions) {
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile secrets and variables with a manifest",
+		Long: `Reconcile GitHub Actions secrets, variables and Dependabot secrets with a desired-state manifest.
+
+The manifest is a YAML file listing organizations, repositories and environments
+along with the secrets, variables and Dependabot secrets each should contain.
+Entries missing from GitHub are created and entries that differ are updated.
+Secret values cannot be read back from GitHub, so existing secrets are always updated.
+
+Secret and Dependabot secret values may reference environment variables using
+${NAME}, so that plaintext values never need to be committed; write $$ for a
+literal $.
+
+Environments listed in the manifest that do not exist yet are skipped with an
+error, leaving the other targets to be applied, unless --create-environment is
+set to create them first.
+
+Manifest Format:
+  organizations:
+    - name: myorg
+      secrets:
+        API_KEY: ${API_KEY}
+      variables:
+        LOG_LEVEL: info
+      dependabot:
+        NPM_TOKEN: ${NPM_TOKEN}
+  repositories:
+    - name: myorg/service
+      variables:
+        PORT: "8080"
+      environments:
+        - name: prod
+          secrets:
+            DB_PASSWORD: ${PROD_DB_PASSWORD}
+
+Pruning:
+  With --prune, entries that exist in GitHub but not in the manifest are deleted.
+  Only kinds listed for a scope are pruned: omit "variables" to leave a scope's
+  variables untouched, or use "variables: {}" to remove all of them.`,
+		Example: `  # Create and update entries to match the manifest
+  $ gh secrets-manager apply -f manifest.yaml
+
+  # Also delete entries that are not in the manifest
+  $ gh secrets-manager apply -f manifest.yaml --prune
+
+  # Create environments the manifest lists that do not exist yet
+  $ gh secrets-manager apply -f manifest.yaml --create-environment
+
+  # Review the changes as JSON without making them
+  $ gh secrets-manager apply -f manifest.yaml --prune --dry-run --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runApply(cmd, opts)
+		},
+	}
+
+	applyCmd.Flags().StringP("file", "f", "", "YAML manifest describing the desired state")
+	applyCmd.Flags().Bool("prune", false, "Delete entries that are not in the manifest")
+	addCreateEnvironmentFlag(applyCmd)
+	addDryRunFlags(applyCmd)
+	addExecutionFlags(applyCmd)
+
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, opts *api.ClientOptions) error {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		return fmt.Errorf("--file flag is required")
+	}
+	prune, _ := cmd.Flags().GetBool("prune")
+
+	m, err := manifest.Load(file)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	createEnvironment, _ := cmd.Flags().GetBool("create-environment")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	p := &plan.Plan{}
+	targets := m.Desired()
+	var missing []string
+	for _, desired := range targets {
+		target := desired.Target
+		if target.IsEnvironment() {
+			if err := ensureEnvironment(cmd, client, target.Repo, target.Environment); err != nil {
+				return fmt.Errorf("failed to create environment %s in %s: %w", target.Environment, target.Repo, err)
+			}
+		}
+
+		state, err := fetchState(client, desired)
+		switch {
+		case err == nil:
+		case target.IsEnvironment() && api.IsNotFound(err) && createEnvironment && dryRun:
+			// The environment would be created, so everything in it is new
+		case target.IsEnvironment() && api.IsNotFound(err):
+			fmt.Fprintf(os.Stderr, "Warning: Skipping %s, the environment does not exist; use --create-environment to create it\n", target)
+			missing = append(missing, target.String())
+			continue
+		default:
+			return fmt.Errorf("failed to read current state of %s: %w", target, err)
+		}
+		p.Add(manifest.Diff(desired, state, prune)...)
+	}
+
+	p, err = retryFailed(cmd, p)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		err = writePlan(cmd, p)
+	} else if err = p.WriteText(os.Stdout); err == nil {
+		err = executePlan(cmd, client, p)
+	}
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	err = fmt.Errorf("skipped %d environments that do not exist: %s", len(missing), strings.Join(missing, ", "))
+	if len(missing) < len(targets) {
+		return &partialFailureError{err: err}
+	}
+	return err
+}
+
+// fetchState lists the entries of each kind the desired state manages for its target
+func fetchState(client *api.Client, desired manifest.Desired) (manifest.State, error) {
+	var state manifest.State
+
+	if desired.Secrets != nil {
+		entries, err := listEntries(client, desired.Target, plan.KindSecret)
+		if err != nil {
+			return state, err
+		}
+		state.Secrets = entryNames(entries)
+	}
+
+	if desired.Variables != nil {
+		entries, err := listEntries(client, desired.Target, plan.KindVariable)
+		if err != nil {
+			return state, err
+		}
+		state.Variables = entries
+	}
+
+	if desired.Dependabot != nil {
+		entries, err := listEntries(client, desired.Target, plan.KindDependabotSecret)
+		if err != nil {
+			return state, err
+		}
+		state.Dependabot = entryNames(entries)
+	}
+
+	return state, nil
+}
+
+func entryNames(entries map[string]string) map[string]bool {
+	names := make(map[string]bool, len(entries))
+	for name := range entries {
+		names[name] = true
+	}
+	return names
+}
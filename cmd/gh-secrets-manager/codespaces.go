@@ -0,0 +1,159 @@
+package main
+
+import (
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/spf13/cobra"
+)
+
+func newCodespacesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "codespaces",
+		Short: "Manage GitHub Codespaces secrets",
+		Long:  `Manage GitHub Codespaces secrets at organization, repository and user levels.`,
+	}
+}
+
+func addCodespacesCommands(rootCmd *cobra.Command, opts *api.ClientOptions) {
+	codespacesCmd := newCodespacesCmd()
+
+	// List codespaces secrets command
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List Codespaces secrets",
+		Long: `List Codespaces secrets at organization, repository or user level.
+
+Usage:
+  # List organization Codespaces secrets
+  $ gh secrets-manager codespaces list --org myorg
+
+  # List repository Codespaces secrets
+  $ gh secrets-manager codespaces list --repo owner/repo
+
+  # List your own Codespaces secrets
+  $ gh secrets-manager codespaces list --user`,
+		Example: `  # List all Codespaces secrets in an organization
+  $ gh secrets-manager codespaces list --org myorg
+
+  # List Codespaces secrets for all frontend team repositories
+  $ gh secrets-manager codespaces list --org myorg --property team --prop_value frontend
+
+  # List your own Codespaces secrets
+  $ gh secrets-manager codespaces list --user`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(cmd, opts, plan.KindCodespacesSecret)
+		},
+	}
+
+	// Set codespaces secrets command
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Create or update Codespaces secrets",
+		Long: `Create or update Codespaces secrets from command line or file input.
+
+Input Methods:
+  1. Command Line:
+     Provide secret name and value directly using flags
+
+  2. File Input:
+     Import secrets from JSON or CSV files
+     Supported formats:
+     - JSON: Array of {"name": "SECRET_NAME", "value": "secret_value"}
+     - CSV: Two columns with headers "name,value"
+
+  3. Bundle Input:
+     Upload secrets encrypted offline by the encrypt command with the
+     public key of the target, so this step never sees the plaintext values
+
+User secrets belong to the authenticated user and require personal access token
+authentication. Use --selected-repos with owner/repo names to choose the repositories
+whose codespaces can use them.
+
+Note: Secret values are encrypted before transmission using GitHub's public key.
+
+Usage:
+  # Set an organization Codespaces secret
+  $ gh secrets-manager codespaces set --org myorg --name NPM_TOKEN --value "1234567890"
+
+  # Set a repository Codespaces secret
+  $ gh secrets-manager codespaces set --repo owner/repo --name NPM_TOKEN --value "1234567890"
+
+  # Set one of your own Codespaces secrets
+  $ gh secrets-manager codespaces set --user --name NPM_TOKEN --value "1234567890" --selected-repos owner/api`,
+		Example: `  # Set a Codespaces secret in an organization
+  $ gh secrets-manager codespaces set --org myorg --name NPM_TOKEN --value "1234567890"
+
+  # Set an organization Codespaces secret that only some repositories can use
+  $ gh secrets-manager codespaces set --org myorg --name NPM_TOKEN --value "1234567890" --selected-repos api,web
+
+  # Import Codespaces secrets for all backend repositories
+  $ gh secrets-manager codespaces set --org myorg --property team --prop_value backend --file codespaces-secrets.json
+
+  # Set one of your own Codespaces secrets for two repositories
+  $ gh secrets-manager codespaces set --user --name NPM_TOKEN --value "1234567890" --selected-repos owner/api,owner/web
+
+  # Upload Codespaces secrets encrypted offline with the repository's Codespaces public key
+  $ gh secrets-manager codespaces set --repo owner/repo --bundle bundle.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSet(cmd, opts, plan.KindCodespacesSecret)
+		},
+	}
+
+	// Delete codespaces secrets command
+	deleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete Codespaces secrets",
+		Long: `Delete Codespaces secrets at organization, repository or user level.
+
+Usage:
+  # Delete an organization Codespaces secret
+  $ gh secrets-manager codespaces delete --org myorg --name SECRET_NAME
+
+  # Delete a repository Codespaces secret
+  $ gh secrets-manager codespaces delete --repo owner/repo --name SECRET_NAME
+
+  # Delete one of your own Codespaces secrets
+  $ gh secrets-manager codespaces delete --user --name SECRET_NAME`,
+		Example: `  # Delete a Codespaces secret from an organization
+  $ gh secrets-manager codespaces delete --org myorg --name NPM_TOKEN
+
+  # Delete a Codespaces secret from all frontend repositories
+  $ gh secrets-manager codespaces delete --org myorg --property team --prop_value frontend --name NPM_TOKEN`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDelete(cmd, opts, plan.KindCodespacesSecret)
+		},
+	}
+
+	// Add common flags to all commands
+	for _, command := range []*cobra.Command{listCmd, setCmd, deleteCmd} {
+		addCommonFlags(command)
+		command.Flags().Bool("user", false, "Use the authenticated user's Codespaces secrets")
+	}
+
+	// Add dry-run and execution flags to mutating commands
+	for _, command := range []*cobra.Command{setCmd, deleteCmd} {
+		addDryRunFlags(command)
+		addExecutionFlags(command)
+	}
+
+	// Add specific flags for set command
+	setCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing secrets (format: array of {\"name\": \"SECRET_NAME\", \"value\": \"secret_value\"})")
+	setCmd.Flags().String("name", "", "Secret name (e.g., NPM_TOKEN)")
+	setCmd.Flags().String("value", "", "Secret value to encrypt and store")
+	addBundleFlag(setCmd)
+	addAccessFlags(setCmd)
+
+	// Add specific flags for delete command
+	deleteCmd.Flags().String("name", "", "Secret name to delete")
+
+	// Add all commands to codespaces command
+	codespacesCmd.AddCommand(listCmd, setCmd, deleteCmd, newReposCmd(opts, "codespaces", selectedRepoEndpoints{
+		noun:   "Codespaces secret",
+		list:   (*api.Client).ListSelectedReposForOrgCodespacesSecret,
+		set:    (*api.Client).SetSelectedReposForOrgCodespacesSecret,
+		add:    (*api.Client).AddSelectedRepoToOrgCodespacesSecret,
+		remove: (*api.Client).RemoveSelectedRepoFromOrgCodespacesSecret,
+	}))
+	rootCmd.AddCommand(codespacesCmd)
+}
+
@@ -18,12 +18,13 @@ var validConfigKeys = map[string]bool{
 	"auth-server":     true,
 	"app-id":          true,
 	"installation-id": true,
+	"host":            true,
 }
 
 // validateConfigKey checks if a configuration key is valid
 func validateConfigKey(key string) error {
 	if !validConfigKeys[key] {
-		return fmt.Errorf("invalid configuration key: %s. Valid keys are: auth-server, app-id, installation-id", key)
+		return fmt.Errorf("invalid configuration key: %s. Valid keys are: auth-server, app-id, installation-id, host", key)
 	}
 	return nil
 }
@@ -83,11 +84,26 @@ func validateURLValue(value string) error {
 	return nil
 }
 
+// validateHostValue validates a GitHub hostname, or an API base URL when a scheme is given
+func validateHostValue(value string) error {
+	if strings.Contains(value, "://") {
+		return validateURLValue(value)
+	}
+
+	parsedURL, err := url.Parse("https://" + value)
+	if err != nil || parsedURL.Host != value {
+		return fmt.Errorf("invalid hostname: %s", value)
+	}
+	return nil
+}
+
 // validateConfigValue checks if a value is valid for the given key
 func validateConfigValue(key, value string) error {
 	switch key {
 	case "auth-server":
 		return validateURLValue(value)
+	case "host":
+		return validateHostValue(value)
 	case "app-id", "installation-id":
 		_, err := validateIntegerValue(key, value)
 		return err
@@ -157,6 +173,8 @@ func newConfigGetCmd() *cobra.Command {
 				fmt.Println(cfg.AppID)
 			case "installation-id":
 				fmt.Println(cfg.InstallationID)
+			case "host":
+				fmt.Println(cfg.Host)
 			}
 			return nil
 		},
@@ -194,6 +212,8 @@ func newConfigSetCmd() *cobra.Command {
 			case "installation-id":
 				id, _ := validateIntegerValue(key, value) // Error already checked by validateConfigValue
 				cfg.InstallationID = id
+			case "host":
+				cfg.Host = value
 			}
 
 			if err := config.Save(cfg); err != nil {
@@ -230,6 +250,8 @@ func newConfigDeleteCmd() *cobra.Command {
 				cfg.AppID = 0
 			case "installation-id":
 				cfg.InstallationID = 0
+			case "host":
+				cfg.Host = ""
 			}
 
 			if err := config.Save(cfg); err != nil {
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/spf13/cobra"
+)
+
+func addCopyCommand(rootCmd *cobra.Command, opts *api.ClientOptions) {
+	copyCmd := &cobra.Command{
+		Use:     "copy",
+		Aliases: []string{"promote"},
+		Short:   "Copy variables and secrets from one scope to another",
+		Long: `Copy variables and secrets from one scope to another, for example to promote the
+configuration of a staging environment to production.
+
+A scope is an organization (myorg), a repository (owner/repo) or a repository
+environment (owner/repo:staging). With --repo, a bare name is an environment of
+that repository.
+
+Variables are read from --from and written to --to with the same values. Secret
+values cannot be read back from GitHub, so secrets are only copied when their
+values are supplied with --secrets-file or --secrets-from-env; the names to copy
+still come from --from. The command fails before changing anything when a secret
+has no value, unless --skip-missing is set.
+
+Copies to an organization require --visibility, since organization entries are
+otherwise visible to all of its repositories.
+
+By default variables are copied, and also Actions secrets when a secret value
+source is given. Use --kind to choose, and --name and --exclude to copy only some
+entries.
+
+Usage:
+  # Promote the variables of staging to production
+  $ gh secrets-manager copy --repo owner/repo --from staging --to production
+
+  # Also promote the secrets, with values from a file
+  $ gh secrets-manager promote --repo owner/repo --from staging --to production --secrets-file prod-secrets.json`,
+		Example: `  # Preview promoting the variables of staging to production
+  $ gh secrets-manager promote --repo owner/repo --from staging --to production --dry-run
+
+  # Copy variables and secrets to another repository, with secret values from the environment
+  $ gh secrets-manager copy --from owner/template --to owner/service --secrets-from-env
+
+  # Copy two variables from a repository to its organization, for private repositories
+  $ gh secrets-manager copy --from myorg/service --to myorg --name API_URL,LOG_LEVEL --visibility private
+
+  # Copy Dependabot secrets between repositories
+  $ gh secrets-manager copy --from owner/a --to owner/b --kind dependabot --secrets-file dependabot.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCopy(cmd, opts)
+		},
+	}
+
+	copyCmd.Flags().String("from", "", "Scope to copy from: org, owner/repo or owner/repo:environment")
+	copyCmd.Flags().String("to", "", "Scope to copy to: org, owner/repo or owner/repo:environment")
+	copyCmd.Flags().StringP("repo", "r", "", "Repository whose environments bare --from and --to names refer to")
+	copyCmd.Flags().StringSlice("kind", nil, "Kinds to copy: variables, secrets and dependabot (default variables, and secrets when values are supplied)")
+	copyCmd.Flags().StringSlice("name", nil, "Comma-separated names of the entries to copy (default all)")
+	copyCmd.Flags().StringSlice("exclude", nil, "Comma-separated names of entries not to copy")
+	copyCmd.Flags().String("secrets-file", "", "JSON/CSV file with the values of the secrets to copy")
+	copyCmd.Flags().Bool("secrets-from-env", false, "Read the value of each secret to copy from the environment variable of the same name")
+	copyCmd.Flags().Bool("skip-missing", false, "Skip secrets without a supplied value instead of failing")
+	addCreateEnvironmentFlag(copyCmd)
+	addAccessFlags(copyCmd)
+	addFingerprintsFlag(copyCmd)
+	addDryRunFlags(copyCmd)
+	addExecutionFlags(copyCmd)
+
+	rootCmd.AddCommand(copyCmd)
+}
+
+func runCopy(cmd *cobra.Command, opts *api.ClientOptions) error {
+	repo, _ := cmd.Flags().GetString("repo")
+	fromFlag, _ := cmd.Flags().GetString("from")
+	toFlag, _ := cmd.Flags().GetString("to")
+	if fromFlag == "" || toFlag == "" {
+		return fmt.Errorf("both --from and --to flags are required")
+	}
+
+	from, err := plan.ParseScope(fromFlag, repo)
+	if err != nil {
+		return err
+	}
+	to, err := plan.ParseScope(toFlag, repo)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("--from and --to name the same scope")
+	}
+
+	copyOpts, err := readCopyOptions(cmd)
+	if err != nil {
+		return err
+	}
+	kindFlags, _ := cmd.Flags().GetStringSlice("kind")
+	kinds, err := plan.CopyKinds(kindFlags, from, to, copyOpts.HasSecretValues())
+	if err != nil {
+		return err
+	}
+
+	if err := copyOpts.CheckAccess(to); err != nil {
+		return err
+	}
+	if createEnvironment, _ := cmd.Flags().GetBool("create-environment"); createEnvironment && !to.IsEnvironment() {
+		return fmt.Errorf("--create-environment requires --to to be an environment")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	p, missing, err := plan.Copy(func(target plan.Target, kind plan.Kind) (map[string]string, error) {
+		return listEntries(client, target, kind)
+	}, from, to, kinds, copyOpts)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		if skip, _ := cmd.Flags().GetBool("skip-missing"); !skip {
+			return fmt.Errorf("no value supplied for %s; use --secrets-file or --secrets-from-env, or --skip-missing to leave them out", strings.Join(missing, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Skipping %s without a supplied value\n", strings.Join(missing, ", "))
+	}
+
+	if to.IsEnvironment() {
+		if err := ensureEnvironment(cmd, client, to.Repo, to.Environment); err != nil {
+			return err
+		}
+	}
+	return runPlan(cmd, client, p)
+}
+
+// readCopyOptions returns the entries to copy, the secret values supplied by
+// --secrets-file and --secrets-from-env and the access requested for the copies
+func readCopyOptions(cmd *cobra.Command) (plan.CopyOptions, error) {
+	var copyOpts plan.CopyOptions
+	copyOpts.Names, _ = cmd.Flags().GetStringSlice("name")
+	copyOpts.Exclude, _ = cmd.Flags().GetStringSlice("exclude")
+	copyOpts.SecretsFromEnv, _ = cmd.Flags().GetBool("secrets-from-env")
+
+	if file, _ := cmd.Flags().GetString("secrets-file"); file != "" {
+		secrets, err := readInputFile(file)
+		if err != nil {
+			return copyOpts, err
+		}
+		copyOpts.SecretValues = make(map[string]string, len(secrets))
+		for _, secret := range secrets {
+			copyOpts.SecretValues[secret.Name] = secret.Value
+		}
+	}
+
+	access, err := readAccess(cmd)
+	if err != nil {
+		return copyOpts, err
+	}
+	copyOpts.Access = access
+	return copyOpts, nil
+}
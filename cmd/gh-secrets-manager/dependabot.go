@@ -1,13 +1,8 @@
 package main
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
-
 	"gh-secrets-manager/pkg/api"
-	fileio "gh-secrets-manager/pkg/io"
-	"github.com/google/go-github/v45/github"
+	"gh-secrets-manager/pkg/plan"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +41,7 @@ Usage:
   # List Dependabot secrets for all frontend team repositories
   $ gh secrets-manager dependabot list --org myorg --property team --prop_value frontend`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runListDependabotSecrets(cmd, opts)
+			return runList(cmd, opts, plan.KindDependabotSecret)
 		},
 	}
 
@@ -66,6 +61,10 @@ Input Methods:
      - JSON: Array of {"name": "SECRET_NAME", "value": "secret_value"}
      - CSV: Two columns with headers "name,value"
 
+  3. Bundle Input:
+     Upload secrets encrypted offline by the encrypt command with the
+     public key of the target, so this step never sees the plaintext values
+
 Common Use Cases:
   - NPM_TOKEN for private npm registry access
   - MAVEN_USERNAME and MAVEN_PASSWORD for private Maven repositories
@@ -93,9 +92,21 @@ Usage:
   $ gh secrets-manager dependabot set --org myorg --file dependabot-secrets.json
 
   # Set Dependabot secret for all backend repositories
-  $ gh secrets-manager dependabot set --org myorg --property team --prop_value backend --name MAVEN_PASSWORD --value "secret123"`,
+  $ gh secrets-manager dependabot set --org myorg --property team --prop_value backend --name MAVEN_PASSWORD --value "secret123"
+
+  # Set an organization Dependabot secret that only some repositories can use
+  $ gh secrets-manager dependabot set --org myorg --name NPM_TOKEN --value "1234567890" --selected-repos api,web
+
+  # Preview which repositories would get the secret without setting it
+  $ gh secrets-manager dependabot set --org myorg --property team --prop_value backend --name MAVEN_PASSWORD --value "secret123" --dry-run
+
+  # Upload Dependabot secrets encrypted offline with the organization's Dependabot public key
+  $ gh secrets-manager dependabot set --org myorg --bundle bundle.json
+
+  # Set a Dependabot secret in three repositories
+  $ gh secrets-manager dependabot set --org myorg --repos api,web,worker --name NPM_TOKEN --value "1234567890"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSetDependabotSecrets(cmd, opts)
+			return runSet(cmd, opts, plan.KindDependabotSecret)
 		},
 	}
 
@@ -121,265 +132,85 @@ Usage:
   $ gh secrets-manager dependabot delete --repo owner/repo --name NUGET_TOKEN
 
   # Delete a Dependabot secret from all frontend repositories
-  $ gh secrets-manager dependabot delete --org myorg --property team --prop_value frontend --name DOCKER_PASSWORD`,
+  $ gh secrets-manager dependabot delete --org myorg --property team --prop_value frontend --name DOCKER_PASSWORD
+
+  # Print the planned deletions as JSON without deleting anything
+  $ gh secrets-manager dependabot delete --org myorg --property team --prop_value frontend --name DOCKER_PASSWORD --dry-run --output json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDeleteDependabotSecrets(cmd, opts)
+			return runDelete(cmd, opts, plan.KindDependabotSecret)
 		},
 	}
 
-	// Add common flags to all commands
-	for _, command := range []*cobra.Command{listCmd, setCmd, deleteCmd} {
-		addCommonFlags(command)
-	}
-
-	// Add specific flags for set command
-	setCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing secrets (format: array of {\"name\": \"SECRET_NAME\", \"value\": \"secret_value\"})")
-	setCmd.Flags().String("name", "", "Secret name (e.g., NPM_TOKEN)")
-	setCmd.Flags().String("value", "", "Secret value to encrypt and store")
-
-	// Add specific flags for delete command
-	deleteCmd.Flags().String("name", "", "Secret name to delete")
-
-	// Add all commands to dependabot command
-	dependabotCmd.AddCommand(listCmd, setCmd, deleteCmd)
-	rootCmd.AddCommand(dependabotCmd)
-}
-
-func runListDependabotSecrets(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-
-	if org != "" {
-		if property != "" && value != "" {
-			// List secrets for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			var results []map[string]interface{}
-			for _, repo := range repos {
-				secrets, err := client.ListRepoDependabotSecrets(org, repo.GetName())
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to list Dependabot secrets for %s: %v\n", repo.GetName(), err)
-					continue
-				}
-				results = append(results, map[string]interface{}{
-					"repository": repo.GetName(),
-					"secrets":    secrets,
-				})
-			}
-			return outputJSON(results)
-		}
-
-		// List organization secrets
-		secrets, err := client.ListOrgDependabotSecrets(org)
-		if err != nil {
-			return err
-		}
-		return outputJSON(secrets)
-	}
+	// Verify Dependabot secrets command
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify Dependabot secrets against recorded fingerprints",
+		Long: `Verify Dependabot secrets against the fingerprint store written by "dependabot set --fingerprints".
 
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		secrets, err := client.ListRepoDependabotSecrets(owner, repoName)
-		if err != nil {
-			return err
-		}
-		return outputJSON(secrets)
-	}
+GitHub never returns secret values, so "dependabot set --fingerprints" records a keyed
+HMAC of each value it writes and the time GitHub reports the secret was updated. The
+key is read from the GH_SECRETS_MANAGER_FINGERPRINT_KEY environment variable.
 
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func runSetDependabotSecrets(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
+Each secret is reported as:
+  ok         unchanged since the tool wrote it
+  modified   updated since the tool wrote it, so it was changed outside the tool
+  mismatch   the desired value given with --file or --name and --value is not the
+             value the tool wrote
+  missing    recorded or desired, but not set
+  untracked  set, but never written with a fingerprint store
 
-	file, _ := cmd.Flags().GetString("file")
-	if file != "" {
-		return handleDependabotFileInput(cmd, client, file)
-	}
+The command fails when any secret is modified, mismatched or missing.
 
-	name, _ := cmd.Flags().GetString("name")
-	value, _ := cmd.Flags().GetString("value")
-	if name == "" || value == "" {
-		return fmt.Errorf("both --name and --value flags are required when not using a file")
-	}
+Usage:
+  # Check that no secret was changed outside the tool
+  $ gh secrets-manager dependabot verify --repo owner/repo --fingerprints fingerprints.json
 
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	propValue, _ := cmd.Flags().GetString("prop_value")
+  # Also check the desired values against the values written
+  $ gh secrets-manager dependabot verify --repo owner/repo --fingerprints fingerprints.json --file secrets.json`,
+		Example: `  # Check the Dependabot secrets of a repository
+  $ gh secrets-manager dependabot verify --repo owner/repo --fingerprints fingerprints.json
 
-	secret := &github.EncryptedSecret{
-		Name:           name,
-		EncryptedValue: value,
+  # Check the Dependabot secrets of every backend repository against the desired values
+  $ gh secrets-manager dependabot verify --org myorg --property team --prop_value backend --fingerprints fingerprints.json --file secrets.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(cmd, opts, plan.KindDependabotSecret)
+		},
 	}
 
-	if org != "" {
-		if property != "" && propValue != "" {
-			// Set secret for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, propValue)
-			if err != nil {
-				return err
-			}
-
-			var lastErr error
-			for _, repo := range repos {
-				if err := client.CreateOrUpdateRepoDependabotSecret(org, repo.GetName(), secret); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to set Dependabot secret for %s: %v\n", repo.GetName(), err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		return client.CreateOrUpdateOrgDependabotSecret(org, secret)
+	// Add common flags to all commands
+	for _, command := range []*cobra.Command{listCmd, setCmd, deleteCmd, verifyCmd} {
+		addCommonFlags(command)
 	}
 
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		return client.CreateOrUpdateRepoDependabotSecret(owner, repoName, secret)
+	// Add dry-run and execution flags to mutating commands
+	for _, command := range []*cobra.Command{setCmd, deleteCmd} {
+		addDryRunFlags(command)
+		addExecutionFlags(command)
 	}
 
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func runDeleteDependabotSecrets(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
+	// Add specific flags for set command
+	setCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing secrets (format: array of {\"name\": \"SECRET_NAME\", \"value\": \"secret_value\"})")
+	setCmd.Flags().String("name", "", "Secret name (e.g., NPM_TOKEN)")
+	setCmd.Flags().String("value", "", "Secret value to encrypt and store")
+	addBundleFlag(setCmd)
+	addAccessFlags(setCmd)
 
-	name, _ := cmd.Flags().GetString("name")
-	if name == "" {
-		return fmt.Errorf("--name flag is required")
-	}
+	// Record fingerprints of the values written, and forget deleted secrets
+	addFingerprintsFlag(setCmd)
+	addFingerprintsFlag(deleteCmd)
 
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-
-	if org != "" {
-		if property != "" && value != "" {
-			// Delete secret from repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			var lastErr error
-			for _, repo := range repos {
-				if err := client.DeleteRepoDependabotSecret(org, repo.GetName(), name); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to delete Dependabot secret from %s: %v\n", repo.GetName(), err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		return client.DeleteOrgDependabotSecret(org, name)
-	}
+	// Add specific flags for delete command
+	deleteCmd.Flags().String("name", "", "Secret name to delete")
 
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		return client.DeleteRepoDependabotSecret(owner, repoName, name)
-	}
+	// Add specific flags for verify command
+	addFingerprintsFlag(verifyCmd)
+	verifyCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing the desired secret values")
+	verifyCmd.Flags().String("name", "", "Secret name of a desired value")
+	verifyCmd.Flags().String("value", "", "Desired secret value")
+	verifyCmd.Flags().String("output", "text", "Output format: text or json")
 
-	return fmt.Errorf("either --org or --repo flag must be specified")
+	// Add all commands to dependabot command
+	dependabotCmd.AddCommand(listCmd, setCmd, deleteCmd, verifyCmd)
+	rootCmd.AddCommand(dependabotCmd)
 }
 
-func handleDependabotFileInput(cmd *cobra.Command, client *api.Client, filePath string) error {
-	ext := filepath.Ext(filePath)
-	var secrets []fileio.SecretData
-	var err error
-
-	switch ext {
-	case ".json":
-		secrets, err = fileio.ReadJSONSecrets(filePath)
-	case ".csv":
-		secrets, err = fileio.ReadCSVSecrets(filePath)
-	default:
-		return fmt.Errorf("unsupported file format: %s", ext)
-	}
-
-	if err != nil {
-		return err
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-
-	var lastErr error
-	if org != "" {
-		if property != "" && value != "" {
-			// Set secrets for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			for _, repo := range repos {
-				for _, secret := range secrets {
-					encSecret := &github.EncryptedSecret{
-						Name:           secret.Name,
-						EncryptedValue: secret.Value,
-					}
-					if err := client.CreateOrUpdateRepoDependabotSecret(org, repo.GetName(), encSecret); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: Failed to set Dependabot secret %s for %s: %v\n", secret.Name, repo.GetName(), err)
-						lastErr = err
-						continue
-					}
-				}
-			}
-			return lastErr
-		}
-
-		// Set organization secrets
-		for _, secret := range secrets {
-			encSecret := &github.EncryptedSecret{
-				Name:           secret.Name,
-				EncryptedValue: secret.Value,
-			}
-			if err := client.CreateOrUpdateOrgDependabotSecret(org, encSecret); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to set organization Dependabot secret %s: %v\n", secret.Name, err)
-				lastErr = err
-				continue
-			}
-		}
-		return lastErr
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		for _, secret := range secrets {
-			encSecret := &github.EncryptedSecret{
-				Name:           secret.Name,
-				EncryptedValue: secret.Value,
-			}
-			if err := client.CreateOrUpdateRepoDependabotSecret(owner, repoName, encSecret); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to set repository Dependabot secret %s: %v\n", secret.Name, err)
-				lastErr = err
-				continue
-			}
-		}
-		return lastErr
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/spf13/cobra"
+)
+
+func addDiffCommand(rootCmd *cobra.Command, opts *api.ClientOptions) {
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the secrets and variables of two scopes",
+		Long: `Compare the secrets, variables and Dependabot secrets of two scopes.
+
+A scope is an organization (myorg), a repository (owner/repo) or a repository
+environment (owner/repo:staging). With --repo, a bare name is an environment of
+that repository.
+
+Secrets and Dependabot secrets are compared by name, since their values cannot be
+read back; variables are compared by name and value. Entries of --from that --to
+lacks are printed with -, entries only --to has with +, and variables whose values
+differ with ~. Environments have no Dependabot secrets, so those are only compared
+between organizations and repositories.
+
+With --exit-code the command exits with status 3 when the scopes differ, 0 when
+they do not and 1 when the comparison fails.
+
+Usage:
+  # Compare two repositories
+  $ gh secrets-manager diff --from owner/repo-a --to owner/repo-b
+
+  # Compare two environments of a repository
+  $ gh secrets-manager diff --repo owner/repo --from staging --to production`,
+		Example: `  # Compare two repositories
+  $ gh secrets-manager diff --from owner/repo-a --to owner/repo-b
+
+  # Compare an organization with one of its repositories
+  $ gh secrets-manager diff --from myorg --to myorg/service
+
+  # Compare the variables of two environments
+  $ gh secrets-manager diff --repo owner/repo --from staging --to production --kind variables
+
+  # Fail a CI job when two repositories differ
+  $ gh secrets-manager diff --from owner/template --to owner/service --exit-code`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(cmd, opts)
+		},
+	}
+
+	diffCmd.Flags().String("from", "", "Scope to compare from: org, owner/repo or owner/repo:environment")
+	diffCmd.Flags().String("to", "", "Scope to compare to: org, owner/repo or owner/repo:environment")
+	diffCmd.Flags().StringP("repo", "r", "", "Repository whose environments bare --from and --to names refer to")
+	diffCmd.Flags().StringSlice("kind", nil, "Kinds to compare: secrets, variables and dependabot (default all)")
+	diffCmd.Flags().String("output", "text", "Output format: text or json")
+	diffCmd.Flags().Bool("exit-code", false, "Exit with status 3 when the scopes differ; errors exit with 1")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffResult is the JSON output of the diff command
+type diffResult struct {
+	From        plan.Target       `json:"from"`
+	To          plan.Target       `json:"to"`
+	Differences []plan.Difference `json:"differences"`
+}
+
+func runDiff(cmd *cobra.Command, opts *api.ClientOptions) error {
+	repo, _ := cmd.Flags().GetString("repo")
+	fromFlag, _ := cmd.Flags().GetString("from")
+	toFlag, _ := cmd.Flags().GetString("to")
+	if fromFlag == "" || toFlag == "" {
+		return fmt.Errorf("both --from and --to flags are required")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	from, err := plan.ParseScope(fromFlag, repo)
+	if err != nil {
+		return err
+	}
+	to, err := plan.ParseScope(toFlag, repo)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("--from and --to name the same scope")
+	}
+
+	kindFlags, _ := cmd.Flags().GetStringSlice("kind")
+	kinds, err := plan.ScopeKinds(kindFlags, from, to)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	diffs, err := plan.Diff(func(target plan.Target, kind plan.Kind) (map[string]string, error) {
+		return listEntries(client, target, kind)
+	}, from, to, kinds...)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		if diffs == nil {
+			diffs = []plan.Difference{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diffResult{From: from, To: to, Differences: diffs})
+	} else {
+		err = plan.WriteDiff(os.Stdout, from, to, diffs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if exitCode, _ := cmd.Flags().GetBool("exit-code"); exitCode && len(diffs) > 0 {
+		return &differencesError{err: fmt.Errorf("%s and %s differ in %d entries", from, to, len(diffs))}
+	}
+	return nil
+}
+
+// differencesError reports that diff --exit-code found differences, which exits with
+// its own code so scripts can tell drift from a failure
+type differencesError struct {
+	err error
+}
+
+func (e *differencesError) Error() string {
+	return e.err.Error()
+}
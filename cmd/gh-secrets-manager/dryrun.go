@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/google/go-github/v45/github"
+	"github.com/spf13/cobra"
+)
+
+func addDryRunFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Print the planned changes without making them")
+	cmd.Flags().String("output", "text", "Format of the dry-run plan: text or json")
+}
+
+// runPlan executes the plan, or prints it with create and update actions
+// resolved against the current state when --dry-run is set
+func runPlan(cmd *cobra.Command, client *api.Client, p *plan.Plan) error {
+	p, err := retryFailed(cmd, p)
+	if err != nil {
+		return err
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if !dryRun {
+		return executePlan(cmd, client, p)
+	}
+
+	createEnvironment, _ := cmd.Flags().GetBool("create-environment")
+	resolved, err := p.Resolve(func(target plan.Target, kind plan.Kind) (map[string]string, error) {
+		entries, err := listEntries(client, target, kind)
+		if createEnvironment && target.IsEnvironment() && api.IsNotFound(err) {
+			// The environment would be created first, so it has no entries yet
+			return map[string]string{}, nil
+		}
+		return entries, err
+	})
+	if err != nil {
+		return err
+	}
+	return writePlan(cmd, resolved)
+}
+
+func writePlan(cmd *cobra.Command, p *plan.Plan) error {
+	output, _ := cmd.Flags().GetString("output")
+	switch output {
+	case "text":
+		return p.WriteText(os.Stdout)
+	case "json":
+		return p.WriteJSON(os.Stdout)
+	}
+	return fmt.Errorf("unsupported output format: %s", output)
+}
+
+// listEntries returns the current entries of a kind at a target, keyed by name.
+// Secret values cannot be read back, so only variables have values.
+func listEntries(client *api.Client, target plan.Target, kind plan.Kind) (map[string]string, error) {
+	if kind == plan.KindVariable {
+		variables, err := listVariables(client, target)
+		if err != nil {
+			return nil, err
+		}
+		entries := make(map[string]string, len(variables))
+		for _, v := range variables {
+			entries[v.Name] = v.Value
+		}
+		return entries, nil
+	}
+
+	secrets, err := listSecrets(client, target, kind)
+	if err != nil {
+		return nil, err
+	}
+	return secretEntries(secrets), nil
+}
+
+func secretEntries(secrets []*github.Secret) map[string]string {
+	entries := make(map[string]string, len(secrets))
+	for _, s := range secrets {
+		entries[s.Name] = ""
+	}
+	return entries
+}
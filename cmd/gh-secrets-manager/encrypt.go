@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-secrets-manager/pkg/encrypt"
+	fileio "gh-secrets-manager/pkg/io"
+	"github.com/spf13/cobra"
+)
+
+func addEncryptCommand(rootCmd *cobra.Command) {
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt secret values offline with a public key",
+		Long: `Encrypt secret values with a GitHub secrets public key without contacting GitHub.
+
+The public key is given with --key-id and --public-key, or read with --key-file from
+the JSON returned by a public-key endpoint, for example:
+  $ gh api repos/owner/repo/actions/secrets/public-key > key.json
+
+A single value, given with --value or read from standard input, is written as the
+request body GitHub expects when setting a secret. With --file, every entry of a
+JSON or CSV secrets file is encrypted into a bundle that "secrets set --bundle",
+"dependabot set --bundle" or "codespaces set --bundle" uploads later, so the step
+holding API credentials never sees the plaintext values.
+
+Each public key belongs to one scope, such as a repository's Actions secrets or an
+organization's Dependabot secrets, so a bundle can only be uploaded to that scope.
+Upload fails if the key was rotated after the bundle was encrypted.`,
+		Example: `  # Encrypt a single value
+  $ gh secrets-manager encrypt --key-id 568250167242549743 --public-key "$PUBLIC_KEY" --value "s3cret"
+
+  # Encrypt a value read from standard input with a saved public key
+  $ printf '%s' "$API_KEY" | gh secrets-manager encrypt --key-file key.json --name API_KEY
+
+  # Encrypt a secrets file into a bundle, then upload it from another job
+  $ gh secrets-manager encrypt --key-file key.json --file secrets.json --out-file bundle.json
+  $ gh secrets-manager secrets set --repo owner/repo --bundle bundle.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEncrypt(cmd)
+		},
+	}
+
+	encryptCmd.Flags().String("key-id", "", "ID of the public key")
+	encryptCmd.Flags().String("public-key", "", "Base64 encoded public key")
+	encryptCmd.Flags().String("key-file", "", "JSON file containing the key_id and key of a public-key endpoint")
+	encryptCmd.Flags().String("name", "", "Secret name to include in the output")
+	encryptCmd.Flags().String("value", "", "Secret value to encrypt (default: read from standard input)")
+	encryptCmd.Flags().StringP("file", "f", "", "JSON/CSV file of secrets to encrypt into a bundle")
+	encryptCmd.Flags().String("out-file", "", "File to write the output to (default: standard output)")
+
+	rootCmd.AddCommand(encryptCmd)
+}
+
+func runEncrypt(cmd *cobra.Command) error {
+	publicKey, err := readPublicKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	var result any
+	if file, _ := cmd.Flags().GetString("file"); file != "" {
+		if cmd.Flags().Changed("value") || cmd.Flags().Changed("name") {
+			return fmt.Errorf("--file cannot be combined with --name or --value")
+		}
+		secrets, err := readInputFile(file)
+		if err != nil {
+			return err
+		}
+		if result, err = publicKey.SealBundle(secrets); err != nil {
+			return err
+		}
+	} else {
+		value, err := readEncryptValue(cmd)
+		if err != nil {
+			return err
+		}
+		payload, err := publicKey.SealPayload(value)
+		if err != nil {
+			return err
+		}
+		result = payload
+		if name, _ := cmd.Flags().GetString("name"); name != "" {
+			result = struct {
+				Name string `json:"name"`
+				*encrypt.Payload
+			}{name, payload}
+		}
+	}
+
+	outFile, _ := cmd.Flags().GetString("out-file")
+	if outFile == "" {
+		return outputJSON(result)
+	}
+	return writeJSONFile(outFile, result)
+}
+
+// readPublicKey returns the key given by --key-file or by --key-id and --public-key
+func readPublicKey(cmd *cobra.Command) (*encrypt.PublicKey, error) {
+	keyFile, _ := cmd.Flags().GetString("key-file")
+	keyID, _ := cmd.Flags().GetString("key-id")
+	key, _ := cmd.Flags().GetString("public-key")
+
+	if keyFile != "" {
+		if keyID != "" || key != "" {
+			return nil, fmt.Errorf("--key-file cannot be combined with --key-id or --public-key")
+		}
+		return encrypt.ReadPublicKey(keyFile)
+	}
+	if keyID == "" || key == "" {
+		return nil, fmt.Errorf("either --key-file or both --key-id and --public-key flags are required")
+	}
+	return encrypt.NewPublicKey(keyID, key)
+}
+
+// readEncryptValue returns --value or, when it is not set, standard input with a
+// single trailing newline removed
+func readEncryptValue(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("value") {
+		value, _ := cmd.Flags().GetString("value")
+		return value, nil
+	}
+
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", fmt.Errorf("failed to read value from standard input: %w", err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if value == "" {
+		return "", fmt.Errorf("no value to encrypt: use --value or pipe it on standard input")
+	}
+	return value, nil
+}
+
+// readBundle returns the secrets of the bundle given by --bundle, or nil when the
+// flag is not set. The bundle is sealed with the key of one scope, so runSet only
+// accepts it for a selection of exactly one target.
+func readBundle(cmd *cobra.Command) ([]fileio.SecretData, error) {
+	path, _ := cmd.Flags().GetString("bundle")
+	if path == "" {
+		return nil, nil
+	}
+	if cmd.Flags().Changed("file") || cmd.Flags().Changed("name") || cmd.Flags().Changed("value") {
+		return nil, fmt.Errorf("--bundle cannot be combined with --file, --name or --value")
+	}
+
+	bundle, err := encrypt.ReadBundle(path)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Entries(), nil
+}
+
+func addBundleFlag(cmd *cobra.Command) {
+	cmd.Flags().String("bundle", "", "Bundle of secrets encrypted offline by the encrypt command")
+}
+
+func writeJSONFile(path string, v any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gh-secrets-manager/pkg/api"
+	"github.com/google/go-github/v45/github"
+	"github.com/spf13/cobra"
+)
+
+// Deployment branch policy modes accepted by --branch-policy
+const (
+	branchPolicyAll       = "all"
+	branchPolicyProtected = "protected"
+	branchPolicyCustom    = "custom"
+)
+
+// newProtectionCmd returns the protection command group reading and setting the
+// protection rules of an environment
+func newProtectionCmd(opts *api.ClientOptions) *cobra.Command {
+	protectionCmd := &cobra.Command{
+		Use:   "protection",
+		Short: "Manage environment protection rules",
+		Long: `Manage the required reviewers, wait timer and deployment branch policy of an environment.
+
+Usage:
+  # Show the protection rules of an environment
+  $ gh secrets-manager environments protection get --repo owner/repo --name prod
+
+  # Require a review from a user and a team
+  $ gh secrets-manager environments protection set --repo owner/repo --name prod --reviewers user:octocat,team:platform
+
+  # Only let main and release tags deploy
+  $ gh secrets-manager environments protection set --repo owner/repo --name prod --branches main --tags "v*"`,
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the protection rules of an environment",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, owner, repoName, name, err := environmentCommandArgs(cmd, opts)
+			if err != nil {
+				return err
+			}
+			protection, err := client.GetEnvironmentProtection(owner, repoName, name)
+			if err != nil {
+				return err
+			}
+			return outputJSON(protection)
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set the protection rules of an environment",
+		Long: `Set the protection rules of an environment, creating it when it does not exist.
+
+Rules are read from --file, in the format printed by "protection get", or from the
+current protection of the environment. Flags then change only the rules they name.
+
+Reviewers are given as user:LOGIN or team:SLUG. --branches and --tags restrict
+deployments to the listed name patterns and imply --branch-policy custom.`,
+		Example: `  # Wait 30 minutes before deployments and require a review from the platform team
+  $ gh secrets-manager environments protection set --repo owner/repo --name prod --wait-timer 30 --reviewers team:platform
+
+  # Only let protected branches deploy
+  $ gh secrets-manager environments protection set --repo owner/repo --name prod --branch-policy protected
+
+  # Copy the protection of another environment
+  $ gh secrets-manager environments protection get --repo owner/repo --name prod > prod.json
+  $ gh secrets-manager environments protection set --repo owner/other --name prod --file prod.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetProtection(cmd, opts)
+		},
+	}
+
+	for _, command := range []*cobra.Command{getCmd, setCmd} {
+		command.Flags().StringP("repo", "r", "", "GitHub repository name")
+		command.Flags().String("name", "", "Environment name")
+	}
+	setCmd.Flags().StringP("file", "f", "", "JSON file containing protection rules")
+	setCmd.Flags().Int("wait-timer", 0, "Minutes to wait before deployments proceed (0-43200)")
+	setCmd.Flags().StringSlice("reviewers", nil, "Comma-separated required reviewers as user:LOGIN or team:SLUG (up to 6)")
+	setCmd.Flags().String("branch-policy", "", "Which branches can deploy: all, protected or custom")
+	setCmd.Flags().StringSlice("branches", nil, "Comma-separated branch name patterns that can deploy")
+	setCmd.Flags().StringSlice("tags", nil, "Comma-separated tag name patterns that can deploy")
+
+	protectionCmd.AddCommand(getCmd, setCmd)
+	return protectionCmd
+}
+
+func runSetProtection(cmd *cobra.Command, opts *api.ClientOptions) error {
+	client, owner, repoName, name, err := environmentCommandArgs(cmd, opts)
+	if err != nil {
+		return err
+	}
+
+	protection, err := baseProtection(cmd, client, owner, repoName, name)
+	if err != nil {
+		return err
+	}
+	if err := applyProtectionFlags(cmd, protection); err != nil {
+		return err
+	}
+	return client.SetEnvironmentProtection(owner, repoName, name, protection)
+}
+
+// baseProtection returns the protection rules read from --file, or the current rules of
+// the environment; a missing environment has none
+func baseProtection(cmd *cobra.Command, client *api.Client, owner, repo, name string) (*api.EnvironmentProtection, error) {
+	file, _ := cmd.Flags().GetString("file")
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		protection := &api.EnvironmentProtection{}
+		if err := json.Unmarshal(data, protection); err != nil {
+			return nil, fmt.Errorf("failed to parse protection rules: %w", err)
+		}
+		return protection, nil
+	}
+
+	protection, err := client.GetEnvironmentProtection(owner, repo, name)
+	if api.IsNotFound(err) {
+		return &api.EnvironmentProtection{}, nil
+	}
+	return protection, err
+}
+
+// applyProtectionFlags changes the protection rules named by the flags that were set
+func applyProtectionFlags(cmd *cobra.Command, protection *api.EnvironmentProtection) error {
+	flags := cmd.Flags()
+
+	if flags.Changed("wait-timer") {
+		protection.WaitTimer, _ = flags.GetInt("wait-timer")
+	}
+
+	if flags.Changed("reviewers") {
+		values, _ := flags.GetStringSlice("reviewers")
+		reviewers, err := parseReviewers(values)
+		if err != nil {
+			return err
+		}
+		protection.Reviewers = reviewers
+	}
+
+	branches, _ := flags.GetStringSlice("branches")
+	tags, _ := flags.GetStringSlice("tags")
+	mode, _ := flags.GetString("branch-policy")
+	patterns := flags.Changed("branches") || flags.Changed("tags")
+	if mode == "" && patterns {
+		mode = branchPolicyCustom
+	}
+	if mode != branchPolicyCustom && patterns {
+		return fmt.Errorf("--branches and --tags require --branch-policy custom")
+	}
+
+	switch mode {
+	case "":
+	case branchPolicyAll:
+		protection.DeploymentBranchPolicy = nil
+		protection.BranchPolicies = nil
+	case branchPolicyProtected:
+		protection.DeploymentBranchPolicy = &github.BranchPolicy{ProtectedBranches: github.Bool(true), CustomBranchPolicies: github.Bool(false)}
+		protection.BranchPolicies = nil
+	case branchPolicyCustom:
+		protection.DeploymentBranchPolicy = &github.BranchPolicy{ProtectedBranches: github.Bool(false), CustomBranchPolicies: github.Bool(true)}
+		if patterns {
+			protection.BranchPolicies = nil
+			for _, branch := range branches {
+				protection.BranchPolicies = append(protection.BranchPolicies, &api.DeploymentBranchPolicy{Name: branch, Type: api.BranchPolicyBranch})
+			}
+			for _, tag := range tags {
+				protection.BranchPolicies = append(protection.BranchPolicies, &api.DeploymentBranchPolicy{Name: tag, Type: api.BranchPolicyTag})
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported branch policy: %s (expected all, protected or custom)", mode)
+	}
+	return nil
+}
+
+// parseReviewers parses reviewers given as user:LOGIN or team:SLUG
+func parseReviewers(values []string) ([]api.EnvironmentReviewer, error) {
+	reviewers := make([]api.EnvironmentReviewer, 0, len(values))
+	for _, value := range values {
+		kind, name, ok := strings.Cut(value, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("reviewer %s must be given as user:LOGIN or team:SLUG", value)
+		}
+		switch strings.ToLower(kind) {
+		case "user":
+			reviewers = append(reviewers, api.EnvironmentReviewer{Type: api.ReviewerUser, Name: name})
+		case "team":
+			reviewers = append(reviewers, api.EnvironmentReviewer{Type: api.ReviewerTeam, Name: name})
+		default:
+			return nil, fmt.Errorf("reviewer %s must be given as user:LOGIN or team:SLUG", value)
+		}
+	}
+	return reviewers, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gh-secrets-manager/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+func newEnvironmentsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "environments",
+		Short: "Manage deployment environments",
+		Long:  `Manage the deployment environments of a repository that environment secrets and variables belong to.`,
+	}
+}
+
+func addEnvironmentCommands(rootCmd *cobra.Command, opts *api.ClientOptions) {
+	environmentsCmd := newEnvironmentsCmd()
+
+	// List environments command
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List environments",
+		Long: `List the deployment environments of a repository.
+
+Usage:
+  # List repository environments
+  $ gh secrets-manager environments list --repo owner/repo`,
+		Example: `  # List the environments of a repository
+  $ gh secrets-manager environments list --repo owner/repo`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runListEnvironments(cmd, opts)
+		},
+	}
+
+	// Create environment command
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an environment",
+		Long: `Create a deployment environment in a repository.
+
+Creating an environment that already exists leaves its protection rules unchanged.
+
+Usage:
+  # Create an environment
+  $ gh secrets-manager environments create --repo owner/repo --name prod`,
+		Example: `  # Create a production environment
+  $ gh secrets-manager environments create --repo owner/repo --name prod`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCreateEnvironment(cmd, opts)
+		},
+	}
+
+	// Delete environment command
+	deleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete an environment",
+		Long: `Delete a deployment environment from a repository.
+
+Deleting an environment also deletes its secrets and variables.
+
+Usage:
+  # Delete an environment
+  $ gh secrets-manager environments delete --repo owner/repo --name staging`,
+		Example: `  # Delete a staging environment
+  $ gh secrets-manager environments delete --repo owner/repo --name staging`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteEnvironment(cmd, opts)
+		},
+	}
+
+	// Export environments command
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export environments with their protection rules",
+		Long: `Export the environments of a repository with their protection rules and
+deployment branch policies as JSON.
+
+The protection of each environment can be applied to another environment with
+"environments protection set --file".
+
+Usage:
+  # Export all environments of a repository
+  $ gh secrets-manager environments export --repo owner/repo`,
+		Example: `  # Save the environments of a repository to a file
+  $ gh secrets-manager environments export --repo owner/repo > environments.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportEnvironments(cmd, opts)
+		},
+	}
+
+	for _, command := range []*cobra.Command{listCmd, createCmd, deleteCmd, exportCmd} {
+		command.Flags().StringP("repo", "r", "", "GitHub repository name")
+	}
+	for _, command := range []*cobra.Command{createCmd, deleteCmd} {
+		command.Flags().String("name", "", "Environment name")
+	}
+
+	environmentsCmd.AddCommand(listCmd, createCmd, deleteCmd, exportCmd, newProtectionCmd(opts))
+	rootCmd.AddCommand(environmentsCmd)
+}
+
+func runListEnvironments(cmd *cobra.Command, opts *api.ClientOptions) error {
+	repo, _ := cmd.Flags().GetString("repo")
+	if repo == "" {
+		return fmt.Errorf("--repo flag is required")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	owner, repoName := splitRepo(repo)
+	environments, err := client.ListEnvironments(owner, repoName)
+	if err != nil {
+		return err
+	}
+	return outputJSON(environments)
+}
+
+// environmentExport is an environment as written by the export command
+type environmentExport struct {
+	Name       string                     `json:"name"`
+	Protection *api.EnvironmentProtection `json:"protection"`
+}
+
+func runExportEnvironments(cmd *cobra.Command, opts *api.ClientOptions) error {
+	repo, _ := cmd.Flags().GetString("repo")
+	if repo == "" {
+		return fmt.Errorf("--repo flag is required")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	owner, repoName := splitRepo(repo)
+	environments, err := client.ListEnvironments(owner, repoName)
+	if err != nil {
+		return err
+	}
+
+	exports := make([]environmentExport, 0, len(environments))
+	for _, environment := range environments {
+		protection, err := client.GetEnvironmentProtection(owner, repoName, environment.GetName())
+		if err != nil {
+			return err
+		}
+		exports = append(exports, environmentExport{Name: environment.GetName(), Protection: protection})
+	}
+	return outputJSON(exports)
+}
+
+func runCreateEnvironment(cmd *cobra.Command, opts *api.ClientOptions) error {
+	client, owner, repoName, name, err := environmentCommandArgs(cmd, opts)
+	if err != nil {
+		return err
+	}
+
+	environment, err := client.CreateOrUpdateEnvironment(owner, repoName, name, nil)
+	if err != nil {
+		return err
+	}
+	return outputJSON(environment)
+}
+
+func runDeleteEnvironment(cmd *cobra.Command, opts *api.ClientOptions) error {
+	client, owner, repoName, name, err := environmentCommandArgs(cmd, opts)
+	if err != nil {
+		return err
+	}
+	return client.DeleteEnvironment(owner, repoName, name)
+}
+
+func environmentCommandArgs(cmd *cobra.Command, opts *api.ClientOptions) (*api.Client, string, string, string, error) {
+	repo, _ := cmd.Flags().GetString("repo")
+	name, _ := cmd.Flags().GetString("name")
+	if repo == "" || name == "" {
+		return nil, "", "", "", fmt.Errorf("both --repo and --name flags are required")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	owner, repoName := splitRepo(repo)
+	return client, owner, repoName, name, nil
+}
+
+func addCreateEnvironmentFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("create-environment", false, "Create the environment first when it does not exist")
+}
+
+// ensureEnvironment creates the target environment when --create-environment is set
+// and it does not exist yet. In a dry run the environment is only looked up.
+func ensureEnvironment(cmd *cobra.Command, client *api.Client, repo, environment string) error {
+	create, _ := cmd.Flags().GetBool("create-environment")
+	if !create {
+		return nil
+	}
+	owner, repoName := splitRepo(repo)
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		_, err := client.GetEnvironment(owner, repoName, environment)
+		if api.IsNotFound(err) {
+			fmt.Fprintf(os.Stderr, "Environment %s does not exist in %s and would be created\n", environment, repo)
+			return nil
+		}
+		return err
+	}
+
+	created, err := client.EnsureEnvironment(owner, repoName, environment)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Fprintf(os.Stderr, "Created environment %s in %s\n", environment, repo)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/google/go-github/v45/github"
+	"github.com/spf13/cobra"
+)
+
+// defaultConcurrency keeps parallel writes low enough to avoid GitHub's secondary rate limits
+const defaultConcurrency = 4
+
+func addExecutionFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("concurrency", defaultConcurrency, "Number of targets to update in parallel")
+	cmd.Flags().String("report", "", "Write a per-change result report to this file")
+	cmd.Flags().String("report-format", "json", "Format of the result report: json or junit")
+	cmd.Flags().String("retry-from", "", "Only run the changes that failed in this JSON report from a previous run")
+}
+
+// retryFailed narrows the plan to the changes that failed in the --retry-from report.
+// The report must come from the same command so its changes line up with the plan.
+func retryFailed(cmd *cobra.Command, p *plan.Plan) (*plan.Plan, error) {
+	path, _ := cmd.Flags().GetString("retry-from")
+	if path == "" {
+		return p, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report: %w", err)
+	}
+	defer f.Close()
+
+	report, err := plan.ReadReport(f)
+	if err != nil {
+		return nil, err
+	}
+	if report.Command != cmd.CommandPath() {
+		return nil, fmt.Errorf("report %s was written by %q, not %q", path, report.Command, cmd.CommandPath())
+	}
+
+	retry := p.Retry(report.Results)
+	fmt.Fprintf(os.Stderr, "Retrying %d of %d changes that failed in %s\n", len(retry.Changes), len(p.Changes), path)
+	return retry, nil
+}
+
+// partialFailureError reports that some, but not all, changes of a batch failed
+type partialFailureError struct {
+	err error
+}
+
+func (e *partialFailureError) Error() string {
+	return e.err.Error()
+}
+
+// executePlan performs the changes with up to --concurrency targets in parallel.
+// A failed change does not stop the others; failures and a summary of the outcomes
+// are printed once every change has run, and a report is written if requested.
+// Secrets written are recorded in the --fingerprints store, if any.
+func executePlan(cmd *cobra.Command, client *api.Client, p *plan.Plan) error {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	reportPath, _ := cmd.Flags().GetString("report")
+	reportFormat, _ := cmd.Flags().GetString("report-format")
+	if reportFormat != "json" && reportFormat != "junit" {
+		return fmt.Errorf("unsupported report format: %s", reportFormat)
+	}
+
+	store, err := openFingerprints(cmd)
+	if err != nil {
+		return err
+	}
+
+	repoIDs := newRepoIDCache(client)
+	results := p.Execute(concurrency, func(c plan.Change) error {
+		if err := executeChange(client, repoIDs, c); err != nil {
+			return err
+		}
+		recordFingerprint(client, store, c)
+		return nil
+	})
+
+	if store != nil {
+		if err := store.Save(); err != nil {
+			return err
+		}
+	}
+
+	if reportPath != "" {
+		if err := writeReport(reportPath, reportFormat, plan.NewReport(cmd.CommandPath(), results)); err != nil {
+			return err
+		}
+	}
+
+	if len(results) == 1 {
+		return results[0].Err
+	}
+
+	if err := results.WriteSummary(os.Stderr); err != nil {
+		return err
+	}
+	if err := results.Err(); err != nil {
+		if results.Count(plan.StatusSucceeded) > 0 {
+			return &partialFailureError{err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+func writeReport(path, format string, report *plan.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer f.Close()
+
+	if format == "junit" {
+		err = report.WriteJUnit(f)
+	} else {
+		err = report.WriteJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return f.Close()
+}
+
+// executeChange performs a single planned change against the GitHub API. Selected
+// repositories are resolved to IDs through the plan's cache.
+func executeChange(client *api.Client, repoIDs *repoIDCache, c plan.Change) error {
+	if c.Kind == plan.KindVariable {
+		if c.Action == plan.ActionDelete {
+			return deleteVariable(client, c.Target, c.Name)
+		}
+		variable, err := changeVariable(repoIDs, c)
+		if err != nil {
+			return err
+		}
+		return setVariable(client, c.Target, variable)
+	}
+
+	scope, err := secretScope(c.Target, c.Kind)
+	if err != nil {
+		return err
+	}
+	if c.Action == plan.ActionDelete {
+		return client.DeleteSecret(scope, c.Name)
+	}
+	secret, err := changeSecret(repoIDs, c)
+	if err != nil {
+		return err
+	}
+	return client.CreateOrUpdateSecret(scope, secret)
+}
+
+// changeSecret returns the secret a set change writes. The value is encrypted by the
+// client unless it was encrypted offline, and the access, if any, has its repository
+// names resolved to IDs.
+func changeSecret(repoIDs *repoIDCache, c plan.Change) (*github.EncryptedSecret, error) {
+	secret := &github.EncryptedSecret{Name: c.Name, KeyID: c.KeyID, EncryptedValue: c.Value}
+	if c.Access == nil {
+		return secret, nil
+	}
+
+	secret.Visibility = c.Access.Visibility
+	if len(c.Access.SelectedRepos) > 0 {
+		ids, err := repoIDs.lookup(c.Target.Org, c.Access.SelectedRepos)
+		if err != nil {
+			return nil, err
+		}
+		secret.SelectedRepositoryIDs = ids
+	}
+	return secret, nil
+}
+
+// changeVariable returns the variable a set change writes, with the access, if any,
+// resolved to repository IDs
+func changeVariable(repoIDs *repoIDCache, c plan.Change) (*api.Variable, error) {
+	variable := &api.Variable{Name: c.Name, Value: c.Value}
+	if c.Access == nil {
+		return variable, nil
+	}
+
+	variable.Visibility = c.Access.Visibility
+	if len(c.Access.SelectedRepos) > 0 {
+		ids, err := repoIDs.lookup(c.Target.Org, c.Access.SelectedRepos)
+		if err != nil {
+			return nil, err
+		}
+		variable.SelectedRepositoryIDs = ids
+	}
+	return variable, nil
+}
+
+func setVariable(client *api.Client, target plan.Target, variable *api.Variable) error {
+	owner, repo := target.SplitRepo()
+	switch {
+	case target.IsOrg():
+		return client.CreateOrUpdateOrgVariable(target.Org, variable)
+	case target.IsEnvironment():
+		return client.CreateOrUpdateEnvironmentVariable(owner, repo, target.Environment, variable)
+	default:
+		return client.CreateOrUpdateRepoVariable(owner, repo, variable)
+	}
+}
+
+func deleteVariable(client *api.Client, target plan.Target, name string) error {
+	owner, repo := target.SplitRepo()
+	switch {
+	case target.IsOrg():
+		return client.DeleteOrgVariable(target.Org, name)
+	case target.IsEnvironment():
+		return client.DeleteEnvironmentVariable(owner, repo, target.Environment, name)
+	default:
+		return client.DeleteRepoVariable(owner, repo, name)
+	}
+}
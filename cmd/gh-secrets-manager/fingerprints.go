@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gh-secrets-manager/pkg/api"
+	"gh-secrets-manager/pkg/fingerprint"
+	fileio "gh-secrets-manager/pkg/io"
+	"gh-secrets-manager/pkg/plan"
+	"github.com/google/go-github/v45/github"
+	"github.com/spf13/cobra"
+)
+
+func addFingerprintsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("fingerprints", "", "Fingerprint store file recording the values written, keyed by "+fingerprint.KeyEnv)
+}
+
+// openFingerprints opens the --fingerprints store, or returns nil when the flag is
+// unset or not registered
+func openFingerprints(cmd *cobra.Command) (*fingerprint.Store, error) {
+	path, _ := cmd.Flags().GetString("fingerprints")
+	if path == "" {
+		return nil, nil
+	}
+	key, err := fingerprint.KeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return fingerprint.Open(path, key)
+}
+
+// recordFingerprint updates the store after a secret change succeeded. The secret is
+// fetched again for the time GitHub updated it. A value encrypted offline has no
+// plaintext to fingerprint, and a failure to record is reported without failing the
+// change, which has already been made.
+func recordFingerprint(client *api.Client, store *fingerprint.Store, c plan.Change) {
+	if store == nil || c.Kind == plan.KindVariable {
+		return
+	}
+	if c.Action == plan.ActionDelete {
+		store.Remove(c.Target, c.Kind, c.Name)
+		return
+	}
+	if c.KeyID != "" {
+		fmt.Fprintf(os.Stderr, "Warning: Not recording a fingerprint of %s in %s, its value was encrypted offline\n", c.Name, c.Target)
+		store.Remove(c.Target, c.Kind, c.Name)
+		return
+	}
+
+	scope, err := secretScope(c.Target, c.Kind)
+	if err == nil {
+		var secret *github.Secret
+		secret, err = client.GetSecret(scope, c.Name)
+		if err == nil {
+			store.Record(c.Target, c.Kind, c.Name, c.Value, secret.UpdatedAt.Time)
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Failed to record fingerprint of %s in %s: %v\n", c.Name, c.Target, err)
+}
+
+// Verification statuses of a secret
+const (
+	verifyOK        = "ok"
+	verifyModified  = "modified"
+	verifyMismatch  = "mismatch"
+	verifyMissing   = "missing"
+	verifyUntracked = "untracked"
+)
+
+// verifyResult is the verification outcome of one secret
+type verifyResult struct {
+	Target plan.Target `json:"target"`
+	Name   string      `json:"name"`
+	Status string      `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+// runVerify compares the secrets of a kind at every selected target with the
+// fingerprint store. A secret drifted when GitHub reports an update after the one
+// recorded, which means it was written outside the tool, or, when desired values are
+// given with --file or --name and --value, when the recorded fingerprint does not
+// match the desired value.
+func runVerify(cmd *cobra.Command, opts *api.ClientOptions, kind plan.Kind) error {
+	path, _ := cmd.Flags().GetString("fingerprints")
+	if path == "" {
+		return fmt.Errorf("--fingerprints flag is required")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	selection, err := readTargetSelection(cmd, kind)
+	if err != nil {
+		return err
+	}
+	store, err := openFingerprints(cmd)
+	if err != nil {
+		return err
+	}
+
+	var desired []fileio.SecretData
+	file, _ := cmd.Flags().GetString("file")
+	name, _ := cmd.Flags().GetString("name")
+	if file != "" || name != "" {
+		desired, err = readInput(cmd)
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	targets, err := selection.resolve(client)
+	if err != nil {
+		return err
+	}
+
+	var results []verifyResult
+	for _, target := range targets {
+		targetResults, err := verifyTarget(client, store, target, kind, desired)
+		if err != nil {
+			return err
+		}
+		results = append(results, targetResults...)
+	}
+
+	if output == "json" {
+		if results == nil {
+			results = []verifyResult{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Status, result.Target, result.Name, result.Detail)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	drifted := 0
+	for _, result := range results {
+		if result.Status == verifyModified || result.Status == verifyMismatch || result.Status == verifyMissing {
+			drifted++
+		}
+	}
+	if drifted > 0 {
+		return fmt.Errorf("%d of %d secrets drifted from the fingerprint store", drifted, len(results))
+	}
+	return nil
+}
+
+// verifyTarget verifies the secrets of a kind at one target. Secrets the tool never
+// recorded are reported as untracked.
+func verifyTarget(client *api.Client, store *fingerprint.Store, target plan.Target, kind plan.Kind, desired []fileio.SecretData) ([]verifyResult, error) {
+	secrets, err := listSecrets(client, target, kind)
+	if err != nil {
+		return nil, err
+	}
+	updated := make(map[string]time.Time, len(secrets))
+	for _, secret := range secrets {
+		updated[secret.Name] = secret.UpdatedAt.Time
+	}
+
+	var results []verifyResult
+	checked := make(map[string]bool)
+	check := func(name string, value *string) {
+		checked[name] = true
+		result := verifyResult{Target: target, Name: name, Status: verifyOK}
+		entry := store.Lookup(target, kind, name)
+		updatedAt, exists := updated[name]
+
+		switch {
+		case entry == nil && !exists:
+			result.Status, result.Detail = verifyMissing, "desired but neither set nor recorded"
+		case entry == nil:
+			result.Status, result.Detail = verifyUntracked, "no fingerprint recorded"
+		case !exists:
+			result.Status, result.Detail = verifyMissing, "recorded but no longer exists"
+		case !updatedAt.Equal(entry.UpdatedAt):
+			result.Status = verifyModified
+			result.Detail = fmt.Sprintf("updated at %s, recorded %s", updatedAt.UTC().Format(time.RFC3339), entry.UpdatedAt.Format(time.RFC3339))
+		case value != nil && !store.Matches(entry, *value):
+			result.Status, result.Detail = verifyMismatch, "desired value differs from the value written"
+		}
+		results = append(results, result)
+	}
+
+	for _, entry := range desired {
+		check(entry.Name, &entry.Value)
+	}
+	for _, entry := range store.Entries(target, kind) {
+		if !checked[entry.Name] {
+			check(entry.Name, nil)
+		}
+	}
+	for _, secret := range secrets {
+		if !checked[secret.Name] {
+			check(secret.Name, nil)
+		}
+	}
+	return results, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -11,16 +12,41 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Exit codes; exitPartialFailure lets CI tell a batch where only some targets failed
+// apart from one that failed outright, and exitDifferences tells scopes that differ
+// under diff --exit-code apart from a diff that failed
+const (
+	exitError          = 1
+	exitPartialFailure = 2
+	exitDifferences    = 3
+)
+
 func main() {
 	cmd := newRootCmd()
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	var partial *partialFailureError
+	var differences *differencesError
+	switch {
+	case errors.As(err, &partial):
+		return exitPartialFailure
+	case errors.As(err, &differences):
+		return exitDifferences
 	}
+	return exitError
 }
 
 func newRootCmd() *cobra.Command {
 	var verbose bool
+	var maxRetries int
+	var hostname string
+	var opts *api.ClientOptions
 
 	cmd := &cobra.Command{
 		Use:     "secrets-manager",
@@ -30,6 +56,25 @@ func newRootCmd() *cobra.Command {
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			// Set the API verbosity based on the flag
 			api.Verbose = verbose
+
+			// Override the retry budget for rate limited and failed requests
+			if cmd.Flags().Changed("max-retries") {
+				policy := api.DefaultRetryPolicy
+				policy.MaxRetries = maxRetries
+				opts.Retry = &policy
+			}
+
+			// Point the client at another GitHub host, looking the username up again there
+			if cmd.Flags().Changed("hostname") {
+				opts.Host = hostname
+				if opts.AuthMethod == api.AuthMethodGitHubApp {
+					username, err := api.GetCurrentUsername(hostname)
+					if err != nil && verbose {
+						fmt.Fprintf(os.Stderr, "Warning: Failed to get current username for organization verification: %v\n", err)
+					}
+					opts.Username = username
+				}
+			}
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
@@ -38,6 +83,8 @@ func newRootCmd() *cobra.Command {
 
 	// Add verbose flag to all commands
 	cmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	cmd.PersistentFlags().StringVar(&hostname, "hostname", "", "GitHub Enterprise Server or GHE.com hostname (default: GH_HOST, the host config key or github.com)")
+	cmd.PersistentFlags().IntVar(&maxRetries, "max-retries", api.DefaultRetryPolicy.MaxRetries, "Maximum retries for rate limited or failed API requests (0 disables retries)")
 
 	// Set custom usage template to ensure gh prefix appears everywhere
 	cmd.SetUsageTemplate(`Usage:
@@ -64,12 +111,14 @@ Use "gh {{.CommandPath}} [command] --help" for more information about a command.
 
 	// Initialize client options - try GitHub App first, fall back to PAT
 	cfg, err := config.Load()
-	var opts *api.ClientOptions
+
+	host := cfg.GitHubHost()
+
 	if err != nil || !cfg.IsGitHubAppConfigured() {
-		opts = &api.ClientOptions{AuthMethod: api.AuthMethodPAT}
+		opts = &api.ClientOptions{AuthMethod: api.AuthMethodPAT, Host: host}
 	} else {
 		// Get current username for organization verification if using GitHub App auth
-		username, err := api.GetCurrentUsername()
+		username, err := api.GetCurrentUsername(host)
 		if err != nil && verbose {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to get current username for organization verification: %v\n", err)
 		}
@@ -80,6 +129,7 @@ Use "gh {{.CommandPath}} [command] --help" for more information about a command.
 			InstallationID: cfg.InstallationID,
 			AuthServer:     cfg.AuthServer,
 			Username:       username,
+			Host:           host,
 		}
 	}
 
@@ -88,6 +138,13 @@ Use "gh {{.CommandPath}} [command] --help" for more information about a command.
 	addSecretCommands(cmd, opts)
 	addVariableCommands(cmd, opts)
 	addDependabotCommands(cmd, opts)
+	addCodespacesCommands(cmd, opts)
+	addEnvironmentCommands(cmd, opts)
+	addApplyCommand(cmd, opts)
+	addPropertyCommands(cmd, opts)
+	addDiffCommand(cmd, opts)
+	addCopyCommand(cmd, opts)
+	addEncryptCommand(cmd)
 
 	return cmd
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"error", errors.New("failed"), exitError},
+		{"partial failure", &partialFailureError{err: errors.New("1 of 2 failed")}, exitPartialFailure},
+		{"differences", &differencesError{err: errors.New("differ")}, exitDifferences},
+		{"wrapped differences", fmt.Errorf("diff: %w", &differencesError{err: errors.New("differ")}), exitDifferences},
+	}
+
+	for _, tc := range tests {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("%s: exitCode = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
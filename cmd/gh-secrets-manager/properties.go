@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gh-secrets-manager/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+func newPropertiesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "properties",
+		Short: "Manage repository custom properties",
+		Long: `Manage the custom properties of an organization's repositories, which --property
+and --select use to target repositories.`,
+	}
+}
+
+func addPropertyCommands(rootCmd *cobra.Command, opts *api.ClientOptions) {
+	propertiesCmd := newPropertiesCmd()
+
+	// List property schemas command
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List custom properties",
+		Long: `List the custom properties an organization defines, with their types, defaults
+and allowed values.
+
+Usage:
+  # List the custom properties of an organization
+  $ gh secrets-manager properties list --org myorg`,
+		Example: `  # List the custom properties of an organization
+  $ gh secrets-manager properties list --org myorg`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runListProperties(cmd, opts)
+		},
+	}
+	listCmd.Flags().StringP("org", "o", "", "GitHub organization name")
+
+	// Get property values command
+	getCmd := &cobra.Command{
+		Use:   "get",
+		Short: "Get custom property values",
+		Long: `Get the custom property values of a repository, or of the repositories of an
+organization.
+
+With --org alone the values of every repository are listed. --repos, --repos-file,
+--repo-pattern, --select, --property and --all-repos narrow the listing as they do
+for secrets.
+
+Usage:
+  # Get the property values of a repository
+  $ gh secrets-manager properties get --repo owner/repo
+
+  # Get the property values of every repository of an organization
+  $ gh secrets-manager properties get --org myorg`,
+		Example: `  # Get the property values of a repository
+  $ gh secrets-manager properties get --repo owner/repo
+
+  # Get the property values of the service repositories
+  $ gh secrets-manager properties get --org myorg --repo-pattern 'svc-*'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetProperties(cmd, opts)
+		},
+	}
+
+	// Set property values command
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set a custom property value",
+		Long: `Set a custom property value on a repository or on many repositories of an
+organization.
+
+Repeat --value to set several values of a multi-select property. --unset removes
+the value instead. Values are checked against the property's definition before
+anything is written.
+
+Usage:
+  # Set a property of a repository
+  $ gh secrets-manager properties set --repo owner/repo --name team --value backend
+
+  # Set a property of several repositories
+  $ gh secrets-manager properties set --org myorg --repos api,worker --name team --value backend`,
+		Example: `  # Tag a repository as owned by the backend team
+  $ gh secrets-manager properties set --repo owner/repo --name team --value backend
+
+  # Set a multi-select property of every service repository
+  $ gh secrets-manager properties set --org myorg --repo-pattern 'svc-*' --name regions --value eu --value us
+
+  # Remove a property value, previewing the repositories first
+  $ gh secrets-manager properties set --org myorg --repos-file repos.txt --name tier --unset --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetProperty(cmd, opts)
+		},
+	}
+	setCmd.Flags().String("name", "", "Custom property name")
+	setCmd.Flags().StringArray("value", nil, "Property value; repeat for multi-select properties")
+	setCmd.Flags().Bool("unset", false, "Remove the property value")
+	addDryRunFlags(setCmd)
+
+	// Resolve repositories command
+	resolveCmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Show the repositories a selection resolves to",
+		Long: `Show the repositories of an organization that a property value, selector
+expression or other repository selection resolves to, without changing anything.
+
+Usage:
+  # Show the repositories with a property value
+  $ gh secrets-manager properties resolve --org myorg --property team --prop_value backend`,
+		Example: `  # Show the repositories with a property value
+  $ gh secrets-manager properties resolve --org myorg --property team --prop_value backend
+
+  # Show the repositories a selector expression matches
+  $ gh secrets-manager properties resolve --org myorg --select 'tier in (1,2) && !archived'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResolveProperties(cmd, opts)
+		},
+	}
+
+	for _, command := range []*cobra.Command{getCmd, setCmd, resolveCmd} {
+		addCommonFlags(command)
+	}
+
+	propertiesCmd.AddCommand(listCmd, getCmd, setCmd, resolveCmd)
+	rootCmd.AddCommand(propertiesCmd)
+}
+
+func runListProperties(cmd *cobra.Command, opts *api.ClientOptions) error {
+	org, _ := cmd.Flags().GetString("org")
+	if org == "" {
+		return fmt.Errorf("--org flag is required")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	schemas, err := client.ListPropertySchemas(org)
+	if err != nil {
+		return err
+	}
+	return outputJSON(schemas)
+}
+
+func runGetProperties(cmd *cobra.Command, opts *api.ClientOptions) error {
+	selection, err := readTargetSelection(cmd, "")
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if selection.repo != "" {
+		owner, repoName := splitRepo(selection.repo)
+		values, err := client.GetRepositoryPropertyValues(owner, repoName)
+		if err != nil {
+			return err
+		}
+		return outputJSON(values)
+	}
+
+	values, err := client.ListOrgPropertyValues(selection.org)
+	if err != nil {
+		return err
+	}
+	if !selection.fanOut() {
+		return outputJSON(values)
+	}
+
+	names, err := selection.repoNames(client)
+	if err != nil {
+		return err
+	}
+	selected := make([]*api.RepositoryPropertyValues, 0, len(names))
+	for _, value := range values {
+		if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, value.RepositoryName) }) {
+			selected = append(selected, value)
+		}
+	}
+	return outputJSON(selected)
+}
+
+func runSetProperty(cmd *cobra.Command, opts *api.ClientOptions) error {
+	name, _ := cmd.Flags().GetString("name")
+	values, _ := cmd.Flags().GetStringArray("value")
+	unset, _ := cmd.Flags().GetBool("unset")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	switch {
+	case name == "":
+		return fmt.Errorf("--name flag is required")
+	case unset && len(values) > 0:
+		return fmt.Errorf("--value and --unset cannot be combined")
+	case !unset && len(values) == 0:
+		return fmt.Errorf("either --value or --unset must be specified")
+	}
+
+	selection, err := readTargetSelection(cmd, "")
+	if err != nil {
+		return err
+	}
+	if selection.repo == "" && !selection.fanOut() {
+		return fmt.Errorf("--org requires --repos, --repos-file, --repo-pattern, --select, --property or --all-repos to choose the repositories to change")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	org := selection.org
+	if selection.repo != "" {
+		org, _ = splitRepo(selection.repo)
+	}
+	schemas, err := client.ListPropertySchemas(org)
+	if err != nil {
+		return err
+	}
+	value, err := propertyValue(schemas, org, name, values, unset)
+	if err != nil {
+		return err
+	}
+
+	repos := []string{selection.repo}
+	if selection.fanOut() {
+		names, err := selection.repoNames(client)
+		if err != nil {
+			return err
+		}
+		repos = repos[:0]
+		for _, repoName := range names {
+			repos = append(repos, org+"/"+repoName)
+		}
+	}
+	if len(repos) == 0 {
+		fmt.Fprintln(os.Stderr, "No repositories matched")
+		return nil
+	}
+
+	action := fmt.Sprintf("set %s=%s", name, strings.Join(values, ","))
+	if unset {
+		action = "unset " + name
+	}
+	if dryRun {
+		return writePropertyPlan(cmd, repos, name, values, unset)
+	}
+
+	if selection.repo != "" {
+		owner, repoName := splitRepo(selection.repo)
+		err = client.SetRepositoryPropertyValues(owner, repoName, []*api.PropertyValue{value})
+	} else {
+		names := make([]string, 0, len(repos))
+		for _, repo := range repos {
+			_, repoName := splitRepo(repo)
+			names = append(names, repoName)
+		}
+		err = client.SetOrgPropertyValues(org, names, []*api.PropertyValue{value})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Successfully %s on %d repositories\n", action, len(repos))
+	return nil
+}
+
+// propertyChange is a planned property assignment printed by properties set --dry-run
+type propertyChange struct {
+	Repository string   `json:"repository"`
+	Property   string   `json:"property"`
+	Action     string   `json:"action"`
+	Values     []string `json:"values,omitempty"`
+}
+
+// writePropertyPlan prints the property assignments of a dry run in the --output
+// format, like writePlan does for secrets and variables
+func writePropertyPlan(cmd *cobra.Command, repos []string, name string, values []string, unset bool) error {
+	changes := make([]propertyChange, 0, len(repos))
+	for _, repo := range repos {
+		change := propertyChange{Repository: repo, Property: name, Action: "set", Values: values}
+		if unset {
+			change.Action = "unset"
+		}
+		changes = append(changes, change)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	switch output {
+	case "text":
+		for _, c := range changes {
+			if c.Action == "unset" {
+				fmt.Printf("Would unset %s on %s\n", c.Property, c.Repository)
+			} else {
+				fmt.Printf("Would set %s=%s on %s\n", c.Property, strings.Join(c.Values, ","), c.Repository)
+			}
+		}
+		return nil
+	case "json":
+		return outputJSON(changes)
+	}
+	return fmt.Errorf("unsupported output format: %s", output)
+}
+
+// propertyValue checks values against the definition of a custom property and
+// returns the value to send. Multi-select properties take a list of values and other
+// types exactly one.
+func propertyValue(schemas []*api.PropertySchema, org, name string, values []string, unset bool) (*api.PropertyValue, error) {
+	i := slices.IndexFunc(schemas, func(schema *api.PropertySchema) bool { return schema.PropertyName == name })
+	if i < 0 {
+		return nil, fmt.Errorf("custom property %q is not defined in organization %s", name, org)
+	}
+	schema := schemas[i]
+
+	if unset {
+		if schema.Required {
+			return nil, fmt.Errorf("custom property %q is required and cannot be unset", name)
+		}
+		return &api.PropertyValue{PropertyName: name}, nil
+	}
+
+	if len(schema.AllowedValues) > 0 {
+		for _, value := range values {
+			if !slices.Contains(schema.AllowedValues, value) {
+				return nil, fmt.Errorf("%q is not an allowed value of custom property %q, expected one of %s",
+					value, name, strings.Join(schema.AllowedValues, ", "))
+			}
+		}
+	}
+	if schema.ValueType == "multi_select" {
+		return &api.PropertyValue{PropertyName: name, Value: values}, nil
+	}
+	if len(values) > 1 {
+		return nil, fmt.Errorf("custom property %q takes a single value", name)
+	}
+	return &api.PropertyValue{PropertyName: name, Value: values[0]}, nil
+}
+
+func runResolveProperties(cmd *cobra.Command, opts *api.ClientOptions) error {
+	selection, err := readTargetSelection(cmd, "")
+	if err != nil {
+		return err
+	}
+	if !selection.fanOut() {
+		return fmt.Errorf("one of --property, --select, --repos, --repos-file, --repo-pattern or --all-repos must be specified")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	names, err := selection.repoNames(client)
+	if err != nil {
+		return err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return outputJSON(names)
+}
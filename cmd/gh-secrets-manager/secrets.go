@@ -9,7 +9,7 @@ import (
 
 	"gh-secrets-manager/pkg/api"
 	fileio "gh-secrets-manager/pkg/io"
-	"github.com/google/go-github/v45/github"
+	"gh-secrets-manager/pkg/plan"
 	"github.com/spf13/cobra"
 )
 
@@ -54,7 +54,7 @@ Usage:
   # List secrets in an environment
   $ gh secrets-manager secrets list --repo owner/repo --environment prod`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runListSecrets(cmd, opts)
+			return runList(cmd, opts, plan.KindSecret)
 		},
 	}
 
@@ -74,6 +74,10 @@ Input Methods:
      - JSON: Array of {"name": "SECRET_NAME", "value": "secret_value"}
      - CSV: Two columns with headers "name,value"
 
+  3. Bundle Input:
+     Upload secrets encrypted offline by the encrypt command with the
+     public key of the target, so this step never sees the plaintext values
+
 Security Notes:
   - All secrets are encrypted using libsodium sealed boxes
   - Values are encrypted before being sent to GitHub
@@ -105,9 +109,33 @@ Usage:
   $ gh secrets-manager secrets set --org myorg --property team --prop_value backend --name DB_PASSWORD --value "secretpass"
 
   # Set secret in an environment
-  $ gh secrets-manager secrets set --repo owner/repo --environment prod --name API_KEY --value "1234567890"`,
+  $ gh secrets-manager secrets set --repo owner/repo --environment prod --name API_KEY --value "1234567890"
+
+  # Set secret in an environment, creating the environment if it does not exist
+  $ gh secrets-manager secrets set --repo owner/repo --environment staging --create-environment --name API_KEY --value "1234567890"
+
+  # Set an organization secret that only some repositories can use
+  $ gh secrets-manager secrets set --org myorg --name NPM_TOKEN --value "npm_XXXXXX" --selected-repos api,web
+
+  # Set an organization secret for private repositories only
+  $ gh secrets-manager secrets set --org myorg --name DEPLOY_KEY --value "XXXXXX" --visibility private
+
+  # Preview which repositories would get a secret created or updated
+  $ gh secrets-manager secrets set --org myorg --property team --prop_value backend --file secrets.json --dry-run
+
+  # Upload secrets encrypted offline with the repository's public key
+  $ gh secrets-manager secrets set --repo owner/repo --bundle bundle.json
+
+  # Set a secret in every service repository except one
+  $ gh secrets-manager secrets set --org myorg --repo-pattern 'svc-*' --exclude svc-legacy --name API_KEY --value "1234567890"
+
+  # Set a secret in the backend team's private payments repositories
+  $ gh secrets-manager secrets set --org myorg --select 'team=backend && topic:payments && visibility=private' --name API_KEY --value "1234567890"
+
+  # Record fingerprints of the values written, for "secrets verify"
+  $ gh secrets-manager secrets set --repo owner/repo --file secrets.json --fingerprints fingerprints.json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSetSecrets(cmd, opts)
+			return runSet(cmd, opts, plan.KindSecret)
 		},
 	}
 
@@ -139,22 +167,77 @@ Usage:
   $ gh secrets-manager secrets delete --org myorg --property team --prop_value frontend --name API_KEY
 
   # Delete a secret from an environment
-  $ gh secrets-manager secrets delete --repo owner/repo --environment prod --name SECRET_NAME`,
+  $ gh secrets-manager secrets delete --repo owner/repo --environment prod --name SECRET_NAME
+
+  # Print the planned deletions as JSON without deleting anything
+  $ gh secrets-manager secrets delete --org myorg --property team --prop_value frontend --name API_KEY --dry-run --output json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDeleteSecrets(cmd, opts)
+			return runDelete(cmd, opts, plan.KindSecret)
+		},
+	}
+
+	// Verify secrets command
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify GitHub Actions secrets against recorded fingerprints",
+		Long: `Verify secrets against the fingerprint store written by "secrets set --fingerprints".
+
+GitHub never returns secret values, so "secrets set --fingerprints" records a keyed
+HMAC of each value it writes and the time GitHub reports the secret was updated. The
+key is read from the GH_SECRETS_MANAGER_FINGERPRINT_KEY environment variable.
+
+Each secret is reported as:
+  ok         unchanged since the tool wrote it
+  modified   updated since the tool wrote it, so it was changed outside the tool
+  mismatch   the desired value given with --file or --name and --value is not the
+             value the tool wrote
+  missing    recorded or desired, but not set
+  untracked  set, but never written with a fingerprint store
+
+The command fails when any secret is modified, mismatched or missing.
+
+Usage:
+  # Check that no secret was changed outside the tool
+  $ gh secrets-manager secrets verify --repo owner/repo --fingerprints fingerprints.json
+
+  # Also check the desired values against the values written
+  $ gh secrets-manager secrets verify --repo owner/repo --fingerprints fingerprints.json --file secrets.json`,
+		Example: `  # Check the secrets of a repository
+  $ gh secrets-manager secrets verify --repo owner/repo --fingerprints fingerprints.json
+
+  # Check the secrets of every backend repository against the desired values
+  $ gh secrets-manager secrets verify --org myorg --property team --prop_value backend --fingerprints fingerprints.json --file secrets.json
+
+  # Verify the secrets of an environment
+  $ gh secrets-manager secrets verify --repo owner/repo --environment prod --fingerprints fingerprints.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(cmd, opts, plan.KindSecret)
 		},
 	}
 
 	// Add common flags to all commands
-	for _, command := range []*cobra.Command{listCmd, setCmd, deleteCmd} {
+	for _, command := range []*cobra.Command{listCmd, setCmd, deleteCmd, verifyCmd} {
 		addCommonFlags(command)
 	}
 
+	// Add dry-run and execution flags to mutating commands
+	for _, command := range []*cobra.Command{setCmd, deleteCmd} {
+		addDryRunFlags(command)
+		addExecutionFlags(command)
+	}
+
 	// Add specific flags for set command
 	setCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing secrets (format: array of {\"name\": \"SECRET_NAME\", \"value\": \"secret_value\"})")
 	setCmd.Flags().String("name", "", "Secret name (e.g., API_KEY)")
 	setCmd.Flags().String("value", "", "Secret value to encrypt and store")
+	addBundleFlag(setCmd)
 	setCmd.Flags().String("environment", "", "GitHub Actions environment name")
+	addCreateEnvironmentFlag(setCmd)
+	addAccessFlags(setCmd)
+
+	// Record fingerprints of the values written, and forget deleted secrets
+	addFingerprintsFlag(setCmd)
+	addFingerprintsFlag(deleteCmd)
 
 	// Add specific flags for delete command
 	deleteCmd.Flags().String("name", "", "Secret name to delete")
@@ -163,288 +246,53 @@ Usage:
 	// Add environment flag to list command
 	listCmd.Flags().String("environment", "", "GitHub Actions environment name")
 
+	// Add specific flags for verify command
+	addFingerprintsFlag(verifyCmd)
+	verifyCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing the desired secret values")
+	verifyCmd.Flags().String("name", "", "Secret name of a desired value")
+	verifyCmd.Flags().String("value", "", "Desired secret value")
+	verifyCmd.Flags().String("output", "text", "Output format: text or json")
+	verifyCmd.Flags().String("environment", "", "GitHub Actions environment name")
+
 	// Add all commands to secrets command
-	secretsCmd.AddCommand(listCmd, setCmd, deleteCmd)
+	secretsCmd.AddCommand(listCmd, setCmd, deleteCmd, verifyCmd, newReposCmd(opts, "secrets", selectedRepoEndpoints{
+		noun:   "secret",
+		list:   (*api.Client).ListSelectedReposForOrgSecret,
+		set:    (*api.Client).SetSelectedReposForOrgSecret,
+		add:    (*api.Client).AddSelectedRepoToOrgSecret,
+		remove: (*api.Client).RemoveSelectedRepoFromOrgSecret,
+	}))
 	rootCmd.AddCommand(secretsCmd)
 }
 
-func addCommonFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP("org", "o", "", "GitHub organization name")
-	cmd.Flags().StringP("repo", "r", "", "GitHub repository name")
-	cmd.Flags().String("property", "", "Custom property name for filtering repositories")
-	cmd.Flags().String("prop_value", "", "Custom property value for filtering repositories")
-}
-
-func runListSecrets(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	if org != "" {
-		if property != "" && value != "" {
-			// List secrets for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			var results []map[string]interface{}
-			for _, repo := range repos {
-				secrets, err := client.ListRepoSecrets(org, repo.GetName())
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to list secrets for %s: %v\n", repo.GetName(), err)
-					continue
-				}
-				results = append(results, map[string]interface{}{
-					"repository": repo.GetName(),
-					"secrets":    secrets,
-				})
-			}
-			return outputJSON(results)
-		}
-
-		// List organization secrets
-		secrets, err := client.ListOrgSecrets(org)
-		if err != nil {
-			return err
-		}
-		return outputJSON(secrets)
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			secrets, err := client.ListEnvironmentSecrets(owner, repoName, environment)
-			if err != nil {
-				return err
-			}
-			return outputJSON(secrets)
-		}
-
-		secrets, err := client.ListRepoSecrets(owner, repoName)
-		if err != nil {
-			return err
-		}
-		return outputJSON(secrets)
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func runSetSecrets(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
+// readInput returns the entries from --bundle or --file, or the single entry given by --name and --value
+func readInput(cmd *cobra.Command) ([]fileio.SecretData, error) {
+	if secrets, err := readBundle(cmd); secrets != nil || err != nil {
+		return secrets, err
 	}
 
 	file, _ := cmd.Flags().GetString("file")
 	if file != "" {
-		return handleFileInput(cmd, client, file)
+		return readInputFile(file)
 	}
 
 	name, _ := cmd.Flags().GetString("name")
 	value, _ := cmd.Flags().GetString("value")
 	if name == "" || value == "" {
-		return fmt.Errorf("both --name and --value flags are required when not using a file")
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	propValue, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	secret := &github.EncryptedSecret{
-		Name:           name,
-		EncryptedValue: value,
-	}
-
-	if org != "" {
-		if property != "" && propValue != "" {
-			// Set secret for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, propValue)
-			if err != nil {
-				return err
-			}
-
-			var lastErr error
-			for _, repo := range repos {
-				if err := client.CreateOrUpdateRepoSecret(org, repo.GetName(), secret); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to set secret for %s: %v\n", repo.GetName(), err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		return client.CreateOrUpdateOrgSecret(org, secret)
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			return client.CreateOrUpdateEnvironmentSecret(owner, repoName, environment, secret)
-		}
-		return client.CreateOrUpdateRepoSecret(owner, repoName, secret)
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func runDeleteSecrets(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
+		return nil, fmt.Errorf("both --name and --value flags are required when not using a file")
 	}
-
-	name, _ := cmd.Flags().GetString("name")
-	if name == "" {
-		return fmt.Errorf("--name flag is required")
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	if org != "" {
-		if property != "" && value != "" {
-			// Delete secret from repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			var lastErr error
-			for _, repo := range repos {
-				if err := client.DeleteRepoSecret(org, repo.GetName(), name); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to delete secret from %s: %v\n", repo.GetName(), err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		return client.DeleteOrgSecret(org, name)
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			return client.DeleteEnvironmentSecret(owner, repoName, environment, name)
-		}
-		return client.DeleteRepoSecret(owner, repoName, name)
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
+	return []fileio.SecretData{{Name: name, Value: value}}, nil
 }
 
-func handleFileInput(cmd *cobra.Command, client *api.Client, filePath string) error {
-	ext := filepath.Ext(filePath)
-	var secrets []fileio.SecretData
-	var err error
-
-	switch ext {
+func readInputFile(filePath string) ([]fileio.SecretData, error) {
+	switch ext := filepath.Ext(filePath); ext {
 	case ".json":
-		secrets, err = fileio.ReadJSONSecrets(filePath)
+		return fileio.ReadJSONSecrets(filePath)
 	case ".csv":
-		secrets, err = fileio.ReadCSVSecrets(filePath)
+		return fileio.ReadCSVSecrets(filePath)
 	default:
-		return fmt.Errorf("unsupported file format: %s", ext)
-	}
-
-	if err != nil {
-		return err
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	var lastErr error
-	if org != "" {
-		if property != "" && value != "" {
-			// Set secrets for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			for _, repo := range repos {
-				for _, secret := range secrets {
-					encSecret := &github.EncryptedSecret{
-						Name:           secret.Name,
-						EncryptedValue: secret.Value,
-					}
-					if err := client.CreateOrUpdateRepoSecret(org, repo.GetName(), encSecret); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: Failed to set secret %s for %s: %v\n", secret.Name, repo.GetName(), err)
-						lastErr = err
-						continue
-					}
-				}
-			}
-			return lastErr
-		}
-
-		// Set organization secrets
-		for _, secret := range secrets {
-			encSecret := &github.EncryptedSecret{
-				Name:           secret.Name,
-				EncryptedValue: secret.Value,
-			}
-			if err := client.CreateOrUpdateOrgSecret(org, encSecret); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to set organization secret %s: %v\n", secret.Name, err)
-				lastErr = err
-				continue
-			}
-		}
-		return lastErr
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			for _, secret := range secrets {
-				encSecret := &github.EncryptedSecret{
-					Name:           secret.Name,
-					EncryptedValue: secret.Value,
-				}
-				if err := client.CreateOrUpdateEnvironmentSecret(owner, repoName, environment, encSecret); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to set environment secret %s: %v\n", secret.Name, err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		for _, secret := range secrets {
-			encSecret := &github.EncryptedSecret{
-				Name:           secret.Name,
-				EncryptedValue: secret.Value,
-			}
-			if err := client.CreateOrUpdateRepoSecret(owner, repoName, encSecret); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to set repository secret %s: %v\n", secret.Name, err)
-				lastErr = err
-				continue
-			}
-		}
-		return lastErr
+		return nil, fmt.Errorf("unsupported file format: %s", ext)
 	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
 }
 
 func splitRepo(repo string) (string, string) {
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gh-secrets-manager/pkg/api"
+	fileio "gh-secrets-manager/pkg/io"
+	"gh-secrets-manager/pkg/plan"
+	"gh-secrets-manager/pkg/selector"
+	"github.com/google/go-github/v45/github"
+	"github.com/spf13/cobra"
+)
+
+func addCommonFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("org", "o", "", "GitHub organization name")
+	cmd.Flags().StringP("repo", "r", "", "GitHub repository name")
+	cmd.Flags().String("property", "", "Custom property name for filtering non-archived repositories")
+	cmd.Flags().String("prop_value", "", "Custom property value for filtering repositories")
+	cmd.Flags().StringSlice("repos", nil, "Comma-separated repositories of --org to target")
+	cmd.Flags().String("repos-file", "", "File listing repositories of --org to target, one per line")
+	cmd.Flags().String("repo-pattern", "", "Only target repositories of --org whose name matches this glob pattern, e.g. 'svc-*'")
+	cmd.Flags().Bool("all-repos", false, "Target every non-archived repository of --org")
+	cmd.Flags().Bool("include-archived", false, "Keep archived repositories in a --property, --all-repos or --repo-pattern set")
+	cmd.Flags().StringSlice("exclude", nil, "Comma-separated repository names or glob patterns to leave out of a multi-repository target")
+	cmd.Flags().String("select", "", "Only target repositories of --org matching this expression, e.g. 'team=backend && !archived && topic:payments'")
+}
+
+// targetSelection is the set of targets a command's flags select: an organization, a
+// repository or one of its environments, the authenticated user, or a set of
+// repositories of an organization.
+//
+// A repository set starts from the repositories named by --repos and --repos-file,
+// those matching a custom property, or every repository of the organization. It is
+// then narrowed by --repo-pattern, --exclude and the --select expression. Archived
+// repositories are only listed with --include-archived or a selector mentioning them.
+type targetSelection struct {
+	org         string
+	repo        string
+	environment string
+	property    string
+	propValue   string
+	user        bool
+	repos       []string
+	pattern     string
+	allRepos    bool
+	archived    bool
+	exclude     []string
+	selector    *selector.Selector
+}
+
+// readTargetSelection reads and validates the target flags of a command. Flags a
+// command does not register read as empty.
+func readTargetSelection(cmd *cobra.Command, kind plan.Kind) (*targetSelection, error) {
+	s := &targetSelection{}
+	s.org, _ = cmd.Flags().GetString("org")
+	s.repo, _ = cmd.Flags().GetString("repo")
+	s.environment, _ = cmd.Flags().GetString("environment")
+	s.property, _ = cmd.Flags().GetString("property")
+	s.propValue, _ = cmd.Flags().GetString("prop_value")
+	s.user, _ = cmd.Flags().GetBool("user")
+	s.repos, _ = cmd.Flags().GetStringSlice("repos")
+	s.pattern, _ = cmd.Flags().GetString("repo-pattern")
+	s.allRepos, _ = cmd.Flags().GetBool("all-repos")
+	s.archived, _ = cmd.Flags().GetBool("include-archived")
+	s.exclude, _ = cmd.Flags().GetStringSlice("exclude")
+
+	if reposFile, _ := cmd.Flags().GetString("repos-file"); reposFile != "" {
+		repos, err := readReposFile(reposFile)
+		if err != nil {
+			return nil, err
+		}
+		s.repos = append(s.repos, repos...)
+		if len(s.repos) == 0 {
+			return nil, fmt.Errorf("%s does not list any repositories", reposFile)
+		}
+	}
+
+	if expr, _ := cmd.Flags().GetString("select"); expr != "" {
+		sel, err := selector.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --select expression: %w", err)
+		}
+		s.selector = sel
+	}
+
+	for _, pattern := range append([]string{s.pattern}, s.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		}
+	}
+
+	sources := 0
+	for _, set := range []bool{len(s.repos) > 0, s.property != "", s.allRepos} {
+		if set {
+			sources++
+		}
+	}
+
+	switch {
+	case s.user && (s.org != "" || s.repo != ""):
+		return nil, fmt.Errorf("--user cannot be combined with --org or --repo")
+	case s.org != "" && s.repo != "":
+		return nil, fmt.Errorf("--org and --repo cannot be combined")
+	case (s.property == "") != (s.propValue == ""):
+		return nil, fmt.Errorf("--property and --prop_value must be used together")
+	case s.fanOut() && s.org == "":
+		return nil, fmt.Errorf("--property, --repos, --repos-file, --repo-pattern, --select and --all-repos require --org")
+	case sources > 1:
+		return nil, fmt.Errorf("only one of --repos/--repos-file, --property and --all-repos can be used")
+	case len(s.exclude) > 0 && !s.fanOut():
+		return nil, fmt.Errorf("--exclude only applies to multiple repository targets")
+	case s.archived && !s.fanOut():
+		return nil, fmt.Errorf("--include-archived only applies to multiple repository targets")
+	case s.environment != "" && s.repo == "":
+		return nil, fmt.Errorf("--environment requires --repo")
+	case s.org == "" && s.repo == "" && !s.user:
+		if kind == plan.KindCodespacesSecret {
+			return nil, fmt.Errorf("one of --org, --repo or --user must be specified")
+		}
+		return nil, fmt.Errorf("either --org or --repo flag must be specified")
+	}
+	return s, nil
+}
+
+// fanOut reports whether the selection resolves to a set of repositories rather than
+// a single named target
+func (s *targetSelection) fanOut() bool {
+	return s.property != "" || len(s.repos) > 0 || s.pattern != "" || s.allRepos || s.selector != nil
+}
+
+// resolve returns the targets of the selection, listing the repositories of a fan-out
+func (s *targetSelection) resolve(client *api.Client) ([]plan.Target, error) {
+	switch {
+	case s.user:
+		return []plan.Target{plan.UserTarget()}, nil
+	case s.repo != "" && s.environment != "":
+		return []plan.Target{plan.EnvironmentTarget(s.repo, s.environment)}, nil
+	case s.repo != "":
+		return []plan.Target{plan.RepoTarget(s.repo)}, nil
+	case s.fanOut():
+		names, err := s.repoNames(client)
+		if err != nil {
+			return nil, err
+		}
+		targets := make([]plan.Target, 0, len(names))
+		for _, name := range names {
+			targets = append(targets, plan.RepoTarget(s.org+"/"+name))
+		}
+		return targets, nil
+	default:
+		return []plan.Target{plan.OrgTarget(s.org)}, nil
+	}
+}
+
+// repoNames returns the names of the repositories in the selection's repository set,
+// without duplicates and in the order they were listed or named
+func (s *targetSelection) repoNames(client *api.Client) ([]string, error) {
+	var names []string
+	var listed []*github.Repository
+	// Archived repositories are read-only, so only a named set, --include-archived or
+	// a selector that asks for them can include them
+	includeArchived := s.archived || (s.selector != nil && s.selector.UsesArchived())
+	switch {
+	case len(s.repos) > 0:
+		for _, repo := range s.repos {
+			name, err := repoName(s.org, repo)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		}
+	case s.property != "":
+		repos, err := client.ListRepositoriesByProperty(s.org, s.property, s.propValue)
+		if err != nil {
+			return nil, err
+		}
+		listed = repos
+		for _, repo := range repos {
+			if includeArchived || !repo.GetArchived() {
+				names = append(names, repo.GetName())
+			}
+		}
+	default:
+		for repo, err := range client.IterOrgRepositories(s.org) {
+			if err != nil {
+				return nil, err
+			}
+			listed = append(listed, repo)
+			if includeArchived || !repo.GetArchived() {
+				names = append(names, repo.GetName())
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(names))
+	selected := names[:0]
+	for _, name := range names {
+		if seen[name] || !s.matches(name) {
+			continue
+		}
+		seen[name] = true
+		selected = append(selected, name)
+	}
+
+	if s.selector == nil {
+		return selected, nil
+	}
+	return s.selectRepos(client, selected, listed)
+}
+
+// selectRepos returns the names that match the --select expression. The expression
+// is evaluated against listed when the repository set already fetched the
+// repositories, or else against a listing of the organization's repositories.
+// Custom property values are only fetched when the expression uses them.
+func (s *targetSelection) selectRepos(client *api.Client, names []string, listed []*github.Repository) ([]string, error) {
+	if listed == nil {
+		repos, err := client.ListOrgRepositories(s.org)
+		if err != nil {
+			return nil, err
+		}
+		listed = repos
+	}
+
+	candidates := make(map[string]*selector.Repository, len(listed))
+	for _, repo := range listed {
+		candidates[strings.ToLower(repo.GetName())] = &selector.Repository{
+			Name:       repo.GetName(),
+			Visibility: repo.GetVisibility(),
+			Archived:   repo.GetArchived(),
+			Fork:       repo.GetFork(),
+			Topics:     repo.Topics,
+		}
+	}
+
+	if s.selector.UsesProperties() {
+		for values, err := range client.IterOrgPropertyValues(s.org) {
+			if err != nil {
+				return nil, err
+			}
+			if candidate, ok := candidates[strings.ToLower(values.RepositoryName)]; ok {
+				candidate.Properties = values.PropertyMap()
+			}
+		}
+	}
+
+	var selected []string
+	for _, name := range names {
+		candidate, ok := candidates[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("repository %s not found in organization %s", name, s.org)
+		}
+		if s.selector.Match(candidate) {
+			selected = append(selected, name)
+		}
+	}
+	return selected, nil
+}
+
+// matches reports whether a repository name passes --repo-pattern and --exclude
+func (s *targetSelection) matches(name string) bool {
+	if s.pattern != "" {
+		if ok, _ := path.Match(s.pattern, name); !ok {
+			return false
+		}
+	}
+	for _, pattern := range s.exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// repoName returns the name of a repository given by name or in owner/repo form,
+// which must belong to org
+func repoName(org, repo string) (string, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return repo, nil
+	}
+	if !strings.EqualFold(owner, org) {
+		return "", fmt.Errorf("repository %s is not in organization %s", repo, org)
+	}
+	return name, nil
+}
+
+// readReposFile reads repository names from a file with one per line. Blank lines and
+// lines starting with # are ignored.
+func readReposFile(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repositories file: %w", err)
+	}
+
+	var repos []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		repos = append(repos, line)
+	}
+	return repos, nil
+}
+
+// kindNoun names the entries of a kind in messages
+func kindNoun(kind plan.Kind) string {
+	switch kind {
+	case plan.KindVariable:
+		return "variables"
+	case plan.KindDependabotSecret:
+		return "Dependabot secrets"
+	case plan.KindCodespacesSecret:
+		return "Codespaces secrets"
+	}
+	return "secrets"
+}
+
+// runList prints the entries of a kind at the selected target, or, for a fan-out,
+// the entries of each matching repository. A repository that cannot be listed is
+// reported and skipped.
+func runList(cmd *cobra.Command, opts *api.ClientOptions, kind plan.Kind) error {
+	selection, err := readTargetSelection(cmd, kind)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	targets, err := selection.resolve(client)
+	if err != nil {
+		return err
+	}
+
+	if !selection.fanOut() {
+		entries, err := listTarget(client, targets[0], kind)
+		if err != nil {
+			return err
+		}
+		return outputJSON(entries)
+	}
+
+	field := "secrets"
+	if kind == plan.KindVariable {
+		field = "variables"
+	}
+	var results []map[string]interface{}
+	for _, target := range targets {
+		_, name := target.SplitRepo()
+		entries, err := listTarget(client, target, kind)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to list %s for %s: %v\n", kindNoun(kind), name, err)
+			continue
+		}
+		results = append(results, map[string]interface{}{
+			"repository": name,
+			field:        entries,
+		})
+	}
+	return outputJSON(results)
+}
+
+// runSet creates or updates the entries read from the command's input at every
+// selected target
+func runSet(cmd *cobra.Command, opts *api.ClientOptions, kind plan.Kind) error {
+	selection, err := readTargetSelection(cmd, kind)
+	if err != nil {
+		return err
+	}
+
+	access, err := readAccess(cmd)
+	if err != nil {
+		return err
+	}
+	if access != nil {
+		switch {
+		case selection.user && access.Visibility != api.VisibilitySelected:
+			return fmt.Errorf("user Codespaces secrets only support --selected-repos")
+		case !selection.user && (selection.org == "" || selection.fanOut()):
+			if kind == plan.KindCodespacesSecret {
+				return fmt.Errorf("--visibility and --selected-repos only apply to organization and user Codespaces secrets")
+			}
+			return fmt.Errorf("--visibility and --selected-repos only apply to organization %s", kindNoun(kind))
+		}
+	}
+	if createEnvironment, _ := cmd.Flags().GetBool("create-environment"); createEnvironment && selection.environment == "" {
+		return fmt.Errorf("--create-environment requires --repo and --environment")
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	entries, err := readInput(cmd)
+	if err != nil {
+		return err
+	}
+
+	targets, err := selection.resolve(client)
+	if err != nil {
+		return err
+	}
+	if bundle, _ := cmd.Flags().GetString("bundle"); bundle != "" && len(targets) != 1 {
+		return fmt.Errorf("--bundle is sealed with the public key of one scope and cannot be uploaded to %d targets", len(targets))
+	}
+
+	p := &plan.Plan{}
+	for _, target := range targets {
+		if target.IsEnvironment() {
+			if err := ensureEnvironment(cmd, client, target.Repo, target.Environment); err != nil {
+				return err
+			}
+		}
+		p.Add(withAccess(setChanges(target, kind, entries), access)...)
+	}
+	return runPlan(cmd, client, p)
+}
+
+// runDelete deletes the entry named by --name from every selected target
+func runDelete(cmd *cobra.Command, opts *api.ClientOptions, kind plan.Kind) error {
+	name, _ := cmd.Flags().GetString("name")
+	if name == "" {
+		return fmt.Errorf("--name flag is required")
+	}
+
+	selection, err := readTargetSelection(cmd, kind)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClientWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	targets, err := selection.resolve(client)
+	if err != nil {
+		return err
+	}
+
+	p := &plan.Plan{}
+	for _, target := range targets {
+		p.Add(deleteChange(target, kind, name))
+	}
+	return runPlan(cmd, client, p)
+}
+
+// setChanges returns a change setting each entry at the target
+func setChanges(target plan.Target, kind plan.Kind, entries []fileio.SecretData) []plan.Change {
+	changes := make([]plan.Change, 0, len(entries))
+	for _, entry := range entries {
+		changes = append(changes, plan.Change{
+			Target: target,
+			Kind:   kind,
+			Name:   entry.Name,
+			Action: plan.ActionSet,
+			Value:  entry.Value,
+			KeyID:  entry.KeyID,
+		})
+	}
+	return changes
+}
+
+func deleteChange(target plan.Target, kind plan.Kind, name string) plan.Change {
+	return plan.Change{Target: target, Kind: kind, Name: name, Action: plan.ActionDelete}
+}
+
+// secretScope returns the API scope holding the secrets of a kind at a target
+func secretScope(target plan.Target, kind plan.Kind) (api.SecretScope, error) {
+	var secretType api.SecretType
+	switch kind {
+	case plan.KindSecret:
+		secretType = api.SecretTypeActions
+	case plan.KindDependabotSecret:
+		secretType = api.SecretTypeDependabot
+	case plan.KindCodespacesSecret:
+		secretType = api.SecretTypeCodespaces
+	default:
+		return api.SecretScope{}, fmt.Errorf("%s are not secrets", kindNoun(kind))
+	}
+
+	owner, repo := target.SplitRepo()
+	switch {
+	case target.User:
+		if kind != plan.KindCodespacesSecret {
+			return api.SecretScope{}, fmt.Errorf("users only have Codespaces secrets")
+		}
+		return api.UserSecretScope(), nil
+	case target.IsOrg():
+		return api.OrgSecretScope(secretType, target.Org), nil
+	case target.IsEnvironment():
+		if kind != plan.KindSecret {
+			return api.SecretScope{}, fmt.Errorf("environments do not support %s", kindNoun(kind))
+		}
+		return api.EnvironmentSecretScope(owner, repo, target.Environment), nil
+	default:
+		return api.RepoSecretScope(secretType, owner, repo), nil
+	}
+}
+
+// listTarget returns the secrets or variables of a kind at a target
+func listTarget(client *api.Client, target plan.Target, kind plan.Kind) (any, error) {
+	if kind == plan.KindVariable {
+		return listVariables(client, target)
+	}
+	return listSecrets(client, target, kind)
+}
+
+func listSecrets(client *api.Client, target plan.Target, kind plan.Kind) ([]*github.Secret, error) {
+	scope, err := secretScope(target, kind)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListSecrets(scope)
+}
+
+func listVariables(client *api.Client, target plan.Target) ([]*api.Variable, error) {
+	owner, repo := target.SplitRepo()
+	switch {
+	case target.User:
+		return nil, fmt.Errorf("users do not have variables")
+	case target.IsOrg():
+		return client.ListOrgVariables(target.Org)
+	case target.IsEnvironment():
+		return client.ListEnvironmentVariables(owner, repo, target.Environment)
+	default:
+		return client.ListRepoVariables(owner, repo)
+	}
+}
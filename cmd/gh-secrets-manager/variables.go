@@ -1,12 +1,8 @@
 package main
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
-
 	"gh-secrets-manager/pkg/api"
-	fileio "gh-secrets-manager/pkg/io"
+	"gh-secrets-manager/pkg/plan"
 	"github.com/spf13/cobra"
 )
 
@@ -51,7 +47,7 @@ Usage:
   # List variables in all frontend repos
   $ gh secrets-manager variables list --org myorg --property team --prop_value frontend`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runListVariables(cmd, opts)
+			return runList(cmd, opts, plan.KindVariable)
 		},
 	}
 
@@ -96,9 +92,21 @@ Usage:
   $ gh secrets-manager variables set --org myorg --property team --prop_value backend --name LOG_LEVEL --value "info"
 
   # Set variable in an environment
-  $ gh secrets-manager variables set --repo owner/repo --environment prod --name API_URL --value "api.example.com"`,
+  $ gh secrets-manager variables set --repo owner/repo --environment prod --name API_URL --value "api.example.com"
+
+  # Set variable in an environment, creating the environment if it does not exist
+  $ gh secrets-manager variables set --repo owner/repo --environment staging --create-environment --name API_URL --value "api.example.com"
+
+  # Set an organization variable that only some repositories can use
+  $ gh secrets-manager variables set --org myorg --name REGION --value "eu-west-1" --selected-repos api,web
+
+  # Preview which variables would be created or changed
+  $ gh secrets-manager variables set --org myorg --property team --prop_value backend --file variables.json --dry-run
+
+  # Set variables in the repositories listed in a file
+  $ gh secrets-manager variables set --org myorg --repos-file repos.txt --file variables.json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSetVariables(cmd, opts)
+			return runSet(cmd, opts, plan.KindVariable)
 		},
 	}
 
@@ -130,9 +138,12 @@ Usage:
   $ gh secrets-manager variables delete --org myorg --property team --prop_value frontend --name API_URL
 
   # Delete a variable from an environment
-  $ gh secrets-manager variables delete --repo owner/repo --environment prod --name API_URL`,
+  $ gh secrets-manager variables delete --repo owner/repo --environment prod --name API_URL
+
+  # Print the planned deletions as JSON without deleting anything
+  $ gh secrets-manager variables delete --org myorg --property team --prop_value frontend --name API_URL --dry-run --output json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDeleteVariables(cmd, opts)
+			return runDelete(cmd, opts, plan.KindVariable)
 		},
 	}
 
@@ -142,291 +153,30 @@ Usage:
 		command.Flags().String("environment", "", "GitHub Actions environment name")
 	}
 
+	// Add dry-run and execution flags to mutating commands
+	for _, command := range []*cobra.Command{setCmd, deleteCmd} {
+		addDryRunFlags(command)
+		addExecutionFlags(command)
+	}
+
 	// Add specific flags for set command
 	setCmd.Flags().StringP("file", "f", "", "JSON/CSV file containing variables")
 	setCmd.Flags().String("name", "", "Variable name")
 	setCmd.Flags().String("value", "", "Variable value")
+	addCreateEnvironmentFlag(setCmd)
+	addAccessFlags(setCmd)
 
 	// Add specific flag for delete command
 	deleteCmd.Flags().String("name", "", "Variable name to delete")
 
 	// Add all commands to variables command
-	variablesCmd.AddCommand(listCmd, setCmd, deleteCmd)
+	variablesCmd.AddCommand(listCmd, setCmd, deleteCmd, newReposCmd(opts, "variables", selectedRepoEndpoints{
+		noun:   "variable",
+		list:   (*api.Client).ListSelectedReposForOrgVariable,
+		set:    (*api.Client).SetSelectedReposForOrgVariable,
+		add:    (*api.Client).AddSelectedRepoToOrgVariable,
+		remove: (*api.Client).RemoveSelectedRepoFromOrgVariable,
+	}))
 	rootCmd.AddCommand(variablesCmd)
 }
 
-func runListVariables(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	if org != "" {
-		if property != "" && value != "" {
-			// List variables for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			var results []map[string]interface{}
-			for _, repo := range repos {
-				variables, err := client.ListRepoVariables(org, repo.GetName())
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to list variables for %s: %v\n", repo.GetName(), err)
-					continue
-				}
-				results = append(results, map[string]interface{}{
-					"repository": repo.GetName(),
-					"variables": variables,
-				})
-			}
-			return outputJSON(results)
-		}
-
-		variables, err := client.ListOrgVariables(org)
-		if err != nil {
-			return err
-		}
-		return outputJSON(variables)
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			// List environment variables
-			variables, err := client.ListEnvironmentVariables(owner, repoName, environment)
-			if err != nil {
-				return err
-			}
-			return outputJSON(variables)
-		}
-
-		variables, err := client.ListRepoVariables(owner, repoName)
-		if err != nil {
-			return err
-		}
-		return outputJSON(variables)
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func runSetVariables(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
-
-	file, _ := cmd.Flags().GetString("file")
-	if file != "" {
-		return handleVariableFileInput(cmd, client, file)
-	}
-
-	name, _ := cmd.Flags().GetString("name")
-	value, _ := cmd.Flags().GetString("value")
-	if name == "" || value == "" {
-		return fmt.Errorf("both --name and --value flags are required when not using a file")
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	propValue, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	variable := &api.Variable{
-		Name:  name,
-		Value: value,
-	}
-
-	if org != "" {
-		if property != "" && propValue != "" {
-			// Set variable for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, propValue)
-			if err != nil {
-				return err
-			}
-
-			var lastErr error
-			for _, repo := range repos {
-				if err := client.CreateOrUpdateRepoVariable(org, repo.GetName(), variable); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to set variable for %s: %v\n", repo.GetName(), err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		return client.CreateOrUpdateOrgVariable(org, variable)
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			// Set environment variable
-			return client.CreateOrUpdateEnvironmentVariable(owner, repoName, environment, variable)
-		}
-		return client.CreateOrUpdateRepoVariable(owner, repoName, variable)
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func runDeleteVariables(cmd *cobra.Command, opts *api.ClientOptions) error {
-	client, err := api.NewClientWithOptions(opts)
-	if err != nil {
-		return err
-	}
-
-	name, _ := cmd.Flags().GetString("name")
-	if name == "" {
-		return fmt.Errorf("--name flag is required")
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	if org != "" {
-		if property != "" && value != "" {
-			// Delete variable from repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			var lastErr error
-			for _, repo := range repos {
-				if err := client.DeleteRepoVariable(org, repo.GetName(), name); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to delete variable from %s: %v\n", repo.GetName(), err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		return client.DeleteOrgVariable(org, name)
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			// Delete environment variable
-			return client.DeleteEnvironmentVariable(owner, repoName, environment, name)
-		}
-		return client.DeleteRepoVariable(owner, repoName, name)
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
-
-func handleVariableFileInput(cmd *cobra.Command, client *api.Client, filePath string) error {
-	ext := filepath.Ext(filePath)
-	var variables []fileio.SecretData // Reuse SecretData type since structure is same
-	var err error
-
-	switch ext {
-	case ".json":
-		variables, err = fileio.ReadJSONSecrets(filePath)
-	case ".csv":
-		variables, err = fileio.ReadCSVSecrets(filePath)
-	default:
-		return fmt.Errorf("unsupported file format: %s", ext)
-	}
-
-	if err != nil {
-		return err
-	}
-
-	org, _ := cmd.Flags().GetString("org")
-	repo, _ := cmd.Flags().GetString("repo")
-	property, _ := cmd.Flags().GetString("property")
-	value, _ := cmd.Flags().GetString("prop_value")
-	environment, _ := cmd.Flags().GetString("environment")
-
-	var lastErr error
-	if org != "" {
-		if property != "" && value != "" {
-			// Set variables for repositories matching property
-			repos, err := client.ListRepositoriesByProperty(org, property, value)
-			if err != nil {
-				return err
-			}
-
-			for _, repo := range repos {
-				for _, v := range variables {
-					variable := &api.Variable{
-						Name:  v.Name,
-						Value: v.Value,
-					}
-					if err := client.CreateOrUpdateRepoVariable(org, repo.GetName(), variable); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: Failed to set variable %s for %s: %v\n", v.Name, repo.GetName(), err)
-						lastErr = err
-						continue
-					}
-				}
-			}
-			return lastErr
-		}
-
-		// Set organization variables
-		for _, v := range variables {
-			variable := &api.Variable{
-				Name:  v.Name,
-				Value: v.Value,
-			}
-			if err := client.CreateOrUpdateOrgVariable(org, variable); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to set organization variable %s: %v\n", v.Name, err)
-				lastErr = err
-				continue
-			}
-		}
-		return lastErr
-	}
-
-	if repo != "" {
-		owner, repoName := splitRepo(repo)
-		if environment != "" {
-			// Set environment variables
-			for _, v := range variables {
-				variable := &api.Variable{
-					Name:  v.Name,
-					Value: v.Value,
-				}
-				if err := client.CreateOrUpdateEnvironmentVariable(owner, repoName, environment, variable); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to set environment variable %s: %v\n", v.Name, err)
-					lastErr = err
-					continue
-				}
-			}
-			return lastErr
-		}
-
-		// Set repository variables
-		for _, v := range variables {
-			variable := &api.Variable{
-				Name:  v.Name,
-				Value: v.Value,
-			}
-			if err := client.CreateOrUpdateRepoVariable(owner, repoName, variable); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to set repository variable %s: %v\n", v.Name, err)
-				lastErr = err
-				continue
-			}
-		}
-		return lastErr
-	}
-
-	return fmt.Errorf("either --org or --repo flag must be specified")
-}
\ No newline at end of file
@@ -4,18 +4,21 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"gh-secrets-manager/pkg/config"
 
 	"github.com/cli/go-gh"
+	ghapi "github.com/cli/go-gh/pkg/api"
 	"github.com/google/go-github/v45/github"
-	"golang.org/x/crypto/nacl/box"
 )
 
 // Verbose controls the logging verbosity across the API package
@@ -36,6 +39,18 @@ type ClientOptions struct {
 	Username       string
 	Organization   string
 	Team           string
+	// Retry controls retries of rate limited and failed requests; nil uses DefaultRetryPolicy
+	Retry *RetryPolicy
+	// Host is the GitHub hostname or API base URL; empty uses GH_HOST or gh's default host
+	Host string
+}
+
+// retryPolicy returns the configured retry policy, or the default when none is set
+func (o *ClientOptions) retryPolicy() RetryPolicy {
+	if o == nil || o.Retry == nil {
+		return DefaultRetryPolicy
+	}
+	return *o.Retry
 }
 
 type authResponse struct {
@@ -43,31 +58,39 @@ type authResponse struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// Client is safe for concurrent use; token refreshes are serialized by mu
 type Client struct {
 	github    *github.Client
 	ctx       context.Context
 	opts      *ClientOptions
+	mu        sync.Mutex
+	auth      *authorizedTransport
+	keys      keyCache
 	authToken string
 	expiresAt time.Time
 }
 
-func GetCurrentUsername() (string, error) {
-	// Use gh CLI to get current username
-	client, err := gh.RESTClient(nil)
+// GetCurrentUsername returns the login gh is authenticated as on host; an empty host
+// uses GH_HOST or gh's default host
+func GetCurrentUsername(host string) (string, error) {
+	// Use gh CLI's credentials for the host to get current username
+	host = resolveHost(host)
+	httpClient, err := gh.HTTPClient(&ghapi.ClientOptions{Host: tokenHost(host)})
 	if err != nil {
 		return "", fmt.Errorf("failed to create GitHub client: %w", err)
 	}
 
-	var user struct {
-		Login string `json:"login"`
+	client, err := newGitHubClient(httpClient, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub client: %w", err)
 	}
-	
-	err = client.Get("user", &user)
+
+	user, _, err := client.Users.Get(context.Background(), "")
 	if err != nil {
 		return "", fmt.Errorf("failed to get current user: %w", err)
 	}
 
-	return user.Login, nil
+	return user.GetLogin(), nil
 }
 
 func NewClient() (*Client, error) {
@@ -90,7 +113,7 @@ func NewClient() (*Client, error) {
 		}
 		
 		// Get current username for organization verification if using GitHub App auth
-		username, err := GetCurrentUsername()
+		username, err := GetCurrentUsername(cfg.GitHubHost())
 		if err != nil {
 			if Verbose {
 				log.Printf("Warning: Failed to get current username for organization verification: %v", err)
@@ -106,6 +129,7 @@ func NewClient() (*Client, error) {
 			Username:       username,
 			Organization:   cfg.Organization,
 			Team:           cfg.Team,
+			Host:           cfg.GitHubHost(),
 		})
 	}
 
@@ -113,7 +137,7 @@ func NewClient() (*Client, error) {
 	if Verbose {
 		log.Printf("GitHub App configuration not found, falling back to PAT authentication")
 	}
-	return NewClientWithOptions(&ClientOptions{AuthMethod: AuthMethodPAT})
+	return NewClientWithOptions(&ClientOptions{AuthMethod: AuthMethodPAT, Host: cfg.GitHubHost()})
 }
 
 func NewClientWithOptions(opts *ClientOptions) (*Client, error) {
@@ -121,7 +145,7 @@ func NewClientWithOptions(opts *ClientOptions) (*Client, error) {
 		if Verbose {
 			log.Printf("No options provided, using default PAT auth")
 		}
-		return newPATClient()
+		return newPATClient(nil)
 	}
 
 	switch opts.AuthMethod {
@@ -129,7 +153,7 @@ func NewClientWithOptions(opts *ClientOptions) (*Client, error) {
 		if Verbose {
 			log.Printf("Using PAT authentication")
 		}
-		return newPATClient()
+		return newPATClient(opts)
 
 	case AuthMethodGitHubApp:
 		if Verbose {
@@ -137,9 +161,8 @@ func NewClientWithOptions(opts *ClientOptions) (*Client, error) {
 				opts.AuthServer, opts.AppID, opts.InstallationID, opts.Username, opts.Organization, opts.Team)
 		}
 		client := &Client{
-			ctx:    context.Background(),
-			github: github.NewClient(&http.Client{}),
-			opts:   opts,
+			ctx:  context.Background(),
+			opts: opts,
 		}
 
 		// Get initial token
@@ -149,13 +172,6 @@ func NewClientWithOptions(opts *ClientOptions) (*Client, error) {
 		}
 		log.Printf("Successfully obtained GitHub App token, expires at %s", client.expiresAt)
 
-		// Update GitHub client with the token
-		client.github = github.NewClient(&http.Client{
-			Transport: &authorizedTransport{
-				token: client.authToken,
-			},
-		})
-
 		return client, nil
 
 	default:
@@ -177,24 +193,36 @@ func (t *restTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return nil, fmt.Errorf("invalid REST client type")
 }
 
-func newPATClient() (*Client, error) {
-	// Use gh CLI's built-in REST client which handles auth
-	restClient, err := gh.RESTClient(nil)
+func newPATClient(opts *ClientOptions) (*Client, error) {
+	patOpts := &ClientOptions{AuthMethod: AuthMethodPAT}
+	if opts != nil {
+		patOpts.Retry = opts.Retry
+		patOpts.Host = opts.Host
+	}
+	host := resolveHost(patOpts.Host)
+
+	// Use gh CLI's built-in HTTP client which handles auth with the host's token
+	restClient, err := gh.HTTPClient(&ghapi.ClientOptions{Host: tokenHost(host)})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
 	}
 
-	// Create a transport that uses the REST client directly
+	// Create a transport that uses the REST client directly, retrying rate limited requests
 	httpClient := &http.Client{
-		Transport: &restTransport{
+		Transport: newRetryTransport(&restTransport{
 			client: restClient,
-		},
+		}, opts.retryPolicy()),
+	}
+
+	ghClient, err := newGitHubClient(httpClient, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
 	}
 
 	return &Client{
-		github: github.NewClient(httpClient),
+		github: ghClient,
 		ctx:    context.Background(),
-		opts:   &ClientOptions{AuthMethod: AuthMethodPAT},
+		opts:   patOpts,
 	}, nil
 }
 
@@ -288,22 +316,43 @@ func (c *Client) refreshToken() error {
 	c.authToken = authResp.Token
 	c.expiresAt = authResp.ExpiresAt
 
-	// Update the GitHub client with the new token
-	c.github = github.NewClient(&http.Client{
-		Transport: &authorizedTransport{
-			token: c.authToken,
-		},
-	})
+	// Create the GitHub client on the first token, retrying rate limited requests.
+	// Later refreshes swap the token in place so in-flight requests keep working.
+	if c.auth == nil {
+		auth := &authorizedTransport{}
+		ghClient, err := newGitHubClient(&http.Client{
+			Transport: newRetryTransport(auth, c.opts.retryPolicy()),
+		}, resolveHost(c.opts.Host))
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+		c.auth = auth
+		c.github = ghClient
+	}
+	c.auth.setToken(c.authToken)
 
 	return nil
 }
 
 type authorizedTransport struct {
+	mu    sync.RWMutex
 	token string
 }
 
+func (t *authorizedTransport) setToken(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = token
+}
+
 func (t *authorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Set("Authorization", "Bearer "+t.token)
+	t.mu.RLock()
+	token := t.token
+	t.mu.RUnlock()
+
+	// Clone before modifying headers, as required of a RoundTripper
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	// Add User-Agent as required by GitHub API
 	if req.Header.Get("User-Agent") == "" {
@@ -317,6 +366,9 @@ func (c *Client) ensureValidToken() error {
 		return nil
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// Refresh token if it's expired or will expire in the next minute
 	if time.Now().Add(time.Minute).After(c.expiresAt) {
 		if Verbose {
@@ -340,204 +392,128 @@ func (c *Client) ensureValidToken() error {
 type Variable struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
+	// Visibility, SelectedRepositoryIDs and SelectedRepositoriesURL only apply to organization
+	// variables. SelectedRepositoryIDs is sent on writes; GitHub returns the URL instead.
+	Visibility              string            `json:"visibility,omitempty"`
+	SelectedRepositoryIDs   []int64           `json:"selected_repository_ids,omitempty"`
+	SelectedRepositoriesURL string            `json:"selected_repositories_url,omitempty"`
+	CreatedAt               *github.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt               *github.Timestamp `json:"updated_at,omitempty"`
 }
 
-// Secrets methods
-func (c *Client) ListOrgSecrets(org string) ([]*github.Secret, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("orgs/%s/actions/secrets", org)
-	req, err := c.github.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var response struct {
-		Secrets []*github.Secret `json:"secrets"`
-	}
-	_, err = c.github.Do(c.ctx, req, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list organization secrets: %w", err)
-	}
-	return response.Secrets, nil
+// variableRequest is the body of a variable create or update request
+type variableRequest struct {
+	Name                  string  `json:"name"`
+	Value                 string  `json:"value"`
+	Visibility            string  `json:"visibility,omitempty"`
+	SelectedRepositoryIDs []int64 `json:"selected_repository_ids,omitempty"`
 }
 
-func (c *Client) ListRepoSecrets(owner, repo string) ([]*github.Secret, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	secrets, _, err := c.github.Actions.ListRepoSecrets(c.ctx, owner, repo, &github.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list repository secrets: %w", err)
+// orgVariableRequest returns the request body for an organization variable. Visibility
+// and repositories are only sent when set, so updates keep the existing access.
+func orgVariableRequest(variable *Variable) *variableRequest {
+	return &variableRequest{
+		Name:                  variable.Name,
+		Value:                 variable.Value,
+		Visibility:            variable.Visibility,
+		SelectedRepositoryIDs: variable.SelectedRepositoryIDs,
 	}
-	return secrets.Secrets, nil
 }
 
-func (c *Client) CreateOrUpdateOrgSecret(org string, secret *github.EncryptedSecret) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	encryption, err := c.GetOrgPublicKey(org)
+// upsertVariable updates a variable in a collection with PATCH and, when GitHub reports
+// that it does not exist, creates it with POST to the collection. Organization variables
+// must be created with a visibility, so new ones default to all repositories.
+func (c *Client) upsertVariable(collection string, body *variableRequest) error {
+	req, err := c.github.NewRequest("PATCH", fmt.Sprintf("%s/%s", collection, body.Name), body)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	encryptedSecret, err := encryption.CreateEncryptedSecret(secret.Name, secret.EncryptedValue)
-	if err != nil {
+	_, err = c.github.Do(c.ctx, req, nil)
+	if !IsNotFound(err) {
 		return err
 	}
 
-	// Custom implementation that uses github.Client's underlying HTTP client
-	// instead of using github.Actions.CreateOrUpdateOrgSecret
-	url := fmt.Sprintf("orgs/%s/actions/secrets/%s", org, encryptedSecret.Name)
-	req := struct {
-		EncryptedValue string `json:"encrypted_value"`
-		KeyID          string `json:"key_id"`
-	}{
-		EncryptedValue: encryptedSecret.EncryptedValue,
-		KeyID:          encryptedSecret.KeyID,
+	if Verbose {
+		log.Printf("Variable %s not found in %s, creating it", body.Name, collection)
 	}
-
-	httpReq, err := c.github.NewRequest("PUT", url, req)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if strings.HasPrefix(collection, "orgs/") && body.Visibility == "" {
+		create := *body
+		create.Visibility = VisibilityAll
+		body = &create
 	}
-
-	_, err = c.github.Do(c.ctx, httpReq, nil)
+	req, err = c.github.NewRequest("POST", collection, body)
 	if err != nil {
-		return fmt.Errorf("failed to create/update organization secret: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	_, err = c.github.Do(c.ctx, req, nil)
+	return err
+}
 
-	return nil
+// IsNotFound reports whether err is a GitHub API 404 response
+func IsNotFound(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
 }
 
-func (c *Client) CreateOrUpdateRepoSecret(owner, repo string, secret *github.EncryptedSecret) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
+// repoVariableRequest returns the request body for a repository or environment variable
+func repoVariableRequest(variable *Variable) *variableRequest {
+	return &variableRequest{Name: variable.Name, Value: variable.Value}
+}
 
-	encryption, err := c.GetRepoPublicKey(owner, repo)
-	if err != nil {
-		return err
-	}
+// Secrets methods
+func (c *Client) ListOrgSecrets(org string) ([]*github.Secret, error) {
+	return collect(c.IterOrgSecrets(org))
+}
 
-	encryptedSecret, err := encryption.CreateEncryptedSecret(secret.Name, secret.EncryptedValue)
-	if err != nil {
-		return err
-	}
+// IterOrgSecrets streams organization secrets, fetching pages as they are consumed
+func (c *Client) IterOrgSecrets(org string) iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, fmt.Sprintf("orgs/%s/actions/secrets", org), "secrets", "organization secrets")
+}
 
-	// Custom implementation that uses github.Client's underlying HTTP client
-	// instead of using github.Actions.CreateOrUpdateRepoSecret
-	url := fmt.Sprintf("repos/%s/%s/actions/secrets/%s", owner, repo, encryptedSecret.Name)
-	req := struct {
-		EncryptedValue string `json:"encrypted_value"`
-		KeyID          string `json:"key_id"`
-	}{
-		EncryptedValue: encryptedSecret.EncryptedValue,
-		KeyID:          encryptedSecret.KeyID,
-	}
+func (c *Client) ListRepoSecrets(owner, repo string) ([]*github.Secret, error) {
+	return collect(c.IterRepoSecrets(owner, repo))
+}
 
-	httpReq, err := c.github.NewRequest("PUT", url, req)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+// IterRepoSecrets streams repository secrets, fetching pages as they are consumed
+func (c *Client) IterRepoSecrets(owner, repo string) iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, fmt.Sprintf("repos/%s/%s/actions/secrets", owner, repo), "secrets", "repository secrets")
+}
 
-	_, err = c.github.Do(c.ctx, httpReq, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create/update repository secret: %w", err)
-	}
+func (c *Client) CreateOrUpdateOrgSecret(org string, secret *github.EncryptedSecret) error {
+	return c.CreateOrUpdateSecret(OrgSecretScope(SecretTypeActions, org), secret)
+}
 
-	return nil
+func (c *Client) CreateOrUpdateRepoSecret(owner, repo string, secret *github.EncryptedSecret) error {
+	return c.CreateOrUpdateSecret(RepoSecretScope(SecretTypeActions, owner, repo), secret)
 }
 
 func (c *Client) DeleteOrgSecret(org, secretName string) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	// Custom implementation that uses github.Client's underlying HTTP client
-	// instead of using github.Actions.DeleteOrgSecret
-	url := fmt.Sprintf("orgs/%s/actions/secrets/%s", org, secretName)
-	req, err := c.github.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("failed to delete organization secret: %w", err)
-	}
-
-	return nil
+	return c.DeleteSecret(OrgSecretScope(SecretTypeActions, org), secretName)
 }
 
 func (c *Client) DeleteRepoSecret(owner, repo, secretName string) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	// Custom implementation that uses github.Client's underlying HTTP client
-	// instead of using github.Actions.DeleteRepoSecret
-	url := fmt.Sprintf("repos/%s/%s/actions/secrets/%s", owner, repo, secretName)
-	req, err := c.github.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("failed to delete repository secret: %w", err)
-	}
-
-	return nil
+	return c.DeleteSecret(RepoSecretScope(SecretTypeActions, owner, repo), secretName)
 }
 
 // Variables methods - implemented using custom API calls since the go-github library
 // doesn't support variables yet
 func (c *Client) ListOrgVariables(org string) ([]*Variable, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	req, err := c.github.NewRequest("GET", fmt.Sprintf("orgs/%s/actions/variables", org), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var response struct {
-		Variables []*Variable `json:"variables"`
-	}
-	_, err = c.github.Do(c.ctx, req, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list organization variables: %w", err)
-	}
+	return collect(c.IterOrgVariables(org))
+}
 
-	return response.Variables, nil
+// IterOrgVariables streams organization variables, fetching pages as they are consumed
+func (c *Client) IterOrgVariables(org string) iter.Seq2[*Variable, error] {
+	return paginate[*Variable](c, fmt.Sprintf("orgs/%s/actions/variables", org), "variables", "organization variables")
 }
 
 func (c *Client) ListRepoVariables(owner, repo string) ([]*Variable, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	req, err := c.github.NewRequest("GET", fmt.Sprintf("repos/%s/%s/actions/variables", owner, repo), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var response struct {
-		Variables []*Variable `json:"variables"`
-	}
-	_, err = c.github.Do(c.ctx, req, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list repository variables: %w", err)
-	}
+	return collect(c.IterRepoVariables(owner, repo))
+}
 
-	return response.Variables, nil
+// IterRepoVariables streams repository variables, fetching pages as they are consumed
+func (c *Client) IterRepoVariables(owner, repo string) iter.Seq2[*Variable, error] {
+	return paginate[*Variable](c, fmt.Sprintf("repos/%s/%s/actions/variables", owner, repo), "variables", "repository variables")
 }
 
 func (c *Client) CreateOrUpdateOrgVariable(org string, variable *Variable) error {
@@ -545,14 +521,8 @@ func (c *Client) CreateOrUpdateOrgVariable(org string, variable *Variable) error
 		return err
 	}
 
-	url := fmt.Sprintf("orgs/%s/actions/variables/%s", org, variable.Name)
-	req, err := c.github.NewRequest("PATCH", url, variable)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
+	body := orgVariableRequest(variable)
+	if err := c.upsertVariable(fmt.Sprintf("orgs/%s/actions/variables", org), body); err != nil {
 		return fmt.Errorf("failed to create/update organization variable: %w", err)
 	}
 	return nil
@@ -563,14 +533,8 @@ func (c *Client) CreateOrUpdateRepoVariable(owner, repo string, variable *Variab
 		return err
 	}
 
-	url := fmt.Sprintf("repos/%s/%s/actions/variables/%s", owner, repo, variable.Name)
-	req, err := c.github.NewRequest("PATCH", url, variable)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
+	body := repoVariableRequest(variable)
+	if err := c.upsertVariable(fmt.Sprintf("repos/%s/%s/actions/variables", owner, repo), body); err != nil {
 		return fmt.Errorf("failed to create/update repository variable: %w", err)
 	}
 	return nil
@@ -614,271 +578,74 @@ func (c *Client) DeleteRepoVariable(owner, repo, variableName string) error {
 
 // Dependabot secrets methods
 func (c *Client) ListOrgDependabotSecrets(org string) ([]*github.Secret, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	req, err := c.github.NewRequest("GET", fmt.Sprintf("orgs/%s/dependabot/secrets", org), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var response struct {
-		Secrets []*github.Secret `json:"secrets"`
-	}
-	_, err = c.github.Do(c.ctx, req, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list organization Dependabot secrets: %w", err)
-	}
+	return collect(c.IterOrgDependabotSecrets(org))
+}
 
-	return response.Secrets, nil
+// IterOrgDependabotSecrets streams organization Dependabot secrets, fetching pages as they are consumed
+func (c *Client) IterOrgDependabotSecrets(org string) iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, fmt.Sprintf("orgs/%s/dependabot/secrets", org), "secrets", "organization Dependabot secrets")
 }
 
 func (c *Client) ListRepoDependabotSecrets(owner, repo string) ([]*github.Secret, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	req, err := c.github.NewRequest("GET", fmt.Sprintf("repos/%s/%s/dependabot/secrets", owner, repo), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var response struct {
-		Secrets []*github.Secret `json:"secrets"`
-	}
-	_, err = c.github.Do(c.ctx, req, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list repository Dependabot secrets: %w", err)
-	}
+	return collect(c.IterRepoDependabotSecrets(owner, repo))
+}
 
-	return response.Secrets, nil
+// IterRepoDependabotSecrets streams repository Dependabot secrets, fetching pages as they are consumed
+func (c *Client) IterRepoDependabotSecrets(owner, repo string) iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, fmt.Sprintf("repos/%s/%s/dependabot/secrets", owner, repo), "secrets", "repository Dependabot secrets")
 }
 
 func (c *Client) CreateOrUpdateOrgDependabotSecret(org string, secret *github.EncryptedSecret) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	encryption, err := c.GetOrgDependabotPublicKey(org)
-	if err != nil {
-		return err
-	}
-
-	encryptedSecret, err := encryption.CreateEncryptedSecret(secret.Name, secret.EncryptedValue)
-	if err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("orgs/%s/dependabot/secrets/%s", org, secret.Name)
-	req := struct {
-		EncryptedValue string `json:"encrypted_value"`
-		KeyID          string `json:"key_id"`
-	}{
-		EncryptedValue: encryptedSecret.EncryptedValue,
-		KeyID:          encryptedSecret.KeyID,
-	}
-
-	httpReq, err := c.github.NewRequest("PUT", url, req)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, httpReq, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create/update organization Dependabot secret: %w", err)
-	}
-	return nil
+	return c.CreateOrUpdateSecret(OrgSecretScope(SecretTypeDependabot, org), secret)
 }
 
 func (c *Client) CreateOrUpdateRepoDependabotSecret(owner, repo string, secret *github.EncryptedSecret) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	encryption, err := c.GetRepoDependabotPublicKey(owner, repo)
-	if err != nil {
-		return err
-	}
-
-	encryptedSecret, err := encryption.CreateEncryptedSecret(secret.Name, secret.EncryptedValue)
-	if err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/dependabot/secrets/%s", owner, repo, secret.Name)
-	req, err := c.github.NewRequest("PUT", url, encryptedSecret)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create/update repository Dependabot secret: %w", err)
-	}
-	return nil
+	return c.CreateOrUpdateSecret(RepoSecretScope(SecretTypeDependabot, owner, repo), secret)
 }
 
 func (c *Client) DeleteOrgDependabotSecret(org, secretName string) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("orgs/%s/dependabot/secrets/%s", org, secretName)
-	req, err := c.github.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("failed to delete organization Dependabot secret: %w", err)
-	}
-	return nil
+	return c.DeleteSecret(OrgSecretScope(SecretTypeDependabot, org), secretName)
 }
 
 func (c *Client) DeleteRepoDependabotSecret(owner, repo, secretName string) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/dependabot/secrets/%s", owner, repo, secretName)
-	req, err := c.github.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("failed to delete repository Dependabot secret: %w", err)
-	}
-	return nil
+	return c.DeleteSecret(RepoSecretScope(SecretTypeDependabot, owner, repo), secretName)
 }
 
 // Environment secrets methods
-func (c *Client) GetEnvPublicKey(owner, repo, environment string) (*github.PublicKey, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/public-key", owner, repo, environment)
-	req, err := c.github.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
 
-	key := &github.PublicKey{}
-	_, err = c.github.Do(c.ctx, req, key)
+// GetEnvPublicKey returns the public key of an environment's secrets as GitHub sends
+// it, from the same cache the secret writes use
+func (c *Client) GetEnvPublicKey(owner, repo, environment string) (*github.PublicKey, error) {
+	encryption, err := c.cachedPublicKey(EnvironmentSecretScope(owner, repo, environment))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get environment public key: %w", err)
+		return nil, err
 	}
-	return key, nil
+	return &github.PublicKey{
+		KeyID: github.String(encryption.KeyID),
+		Key:   github.String(base64.StdEncoding.EncodeToString(encryption.PublicKey)),
+	}, nil
 }
 
 func (c *Client) ListEnvSecrets(owner, repo, environment string) ([]*github.Secret, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets", owner, repo, environment)
-	req, err := c.github.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var secrets struct {
-		Secrets []*github.Secret `json:"secrets"`
-	}
-	_, err = c.github.Do(c.ctx, req, &secrets)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list environment secrets: %w", err)
-	}
-	return secrets.Secrets, nil
+	return c.ListEnvironmentSecrets(owner, repo, environment)
 }
 
 func (c *Client) CreateOrUpdateEnvSecret(owner, repo, environment string, secret *github.EncryptedSecret) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	key, err := c.GetEnvPublicKey(owner, repo, environment)
-	if err != nil {
-		return err
-	}
-
-	decodedKey, err := base64.StdEncoding.DecodeString(*key.Key)
-	if err != nil {
-		return fmt.Errorf("failed to decode public key: %w", err)
-	}
-
-	var publicKey [32]byte
-	copy(publicKey[:], decodedKey)
-
-	encryptedBytes, err := box.SealAnonymous(nil, []byte(secret.EncryptedValue), &publicKey, nil)
-	if err != nil {
-		return fmt.Errorf("failed to encrypt secret: %w", err)
-	}
-
-	req := struct {
-		EncryptedValue string `json:"encrypted_value"`
-		KeyID          string `json:"key_id"`
-	}{
-		EncryptedValue: base64.StdEncoding.EncodeToString(encryptedBytes),
-		KeyID:          *key.KeyID,
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/%s", owner, repo, environment, secret.Name)
-	httpReq, err := c.github.NewRequest("PUT", url, req)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, httpReq, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create/update environment secret: %w", err)
-	}
-	return nil
+	return c.CreateOrUpdateEnvironmentSecret(owner, repo, environment, secret)
 }
 
 func (c *Client) DeleteEnvSecret(owner, repo, environment, name string) error {
-	if err := c.ensureValidToken(); err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/%s", owner, repo, environment, name)
-	req, err := c.github.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
-		return fmt.Errorf("failed to delete environment secret: %w", err)
-	}
-	return nil
+	return c.DeleteEnvironmentSecret(owner, repo, environment, name)
 }
 
 // Environment variables methods
 func (c *Client) ListEnvironmentVariables(owner, repo, environment string) ([]*Variable, error) {
-	if err := c.ensureValidToken(); err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables", owner, repo, environment)
-	req, err := c.github.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	var response struct {
-		Variables []*Variable `json:"variables"`
-	}
-	_, err = c.github.Do(c.ctx, req, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list environment variables: %w", err)
-	}
+	return collect(c.IterEnvironmentVariables(owner, repo, environment))
+}
 
-	return response.Variables, nil
+// IterEnvironmentVariables streams environment variables, fetching pages as they are consumed
+func (c *Client) IterEnvironmentVariables(owner, repo, environment string) iter.Seq2[*Variable, error] {
+	return paginate[*Variable](c, environmentURL(owner, repo, environment)+"/variables", "variables", "environment variables")
 }
 
 func (c *Client) CreateOrUpdateEnvironmentVariable(owner, repo, environment string, variable *Variable) error {
@@ -886,14 +653,8 @@ func (c *Client) CreateOrUpdateEnvironmentVariable(owner, repo, environment stri
 		return err
 	}
 
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables/%s", owner, repo, environment, variable.Name)
-	req, err := c.github.NewRequest("PUT", url, variable)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	_, err = c.github.Do(c.ctx, req, nil)
-	if err != nil {
+	body := repoVariableRequest(variable)
+	if err := c.upsertVariable(environmentURL(owner, repo, environment)+"/variables", body); err != nil {
 		return fmt.Errorf("failed to create/update environment variable: %w", err)
 	}
 	return nil
@@ -904,7 +665,7 @@ func (c *Client) DeleteEnvironmentVariable(owner, repo, environment, name string
 		return err
 	}
 
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables/%s", owner, repo, environment, name)
+	url := environmentURL(owner, repo, environment) + "/variables/" + name
 	req, err := c.github.NewRequest("DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -74,13 +74,19 @@ func setupMultiHandlerTestServer(t *testing.T, handlers map[string]http.HandlerF
 	t.Helper()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Printf("[TEST HANDLER] Incoming path: %s\n", r.URL.Path)
-		for prefix, handler := range handlers {
-			if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
-				fmt.Printf("[TEST HANDLER] Matched handler for prefix: %s\n", prefix)
-				handler(w, r)
-				return
+		// Pick the longest matching prefix so that nested paths such as
+		// .../secrets/public-key reach their own handler whatever the map order
+		match := ""
+		for prefix := range handlers {
+			if (r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/")) && len(prefix) > len(match) {
+				match = prefix
 			}
 		}
+		if match != "" {
+			fmt.Printf("[TEST HANDLER] Matched handler for prefix: %s\n", match)
+			handlers[match](w, r)
+			return
+		}
 		if strings.HasSuffix(r.URL.Path, "/public-key") {
 			fmt.Printf("[TEST HANDLER] Catch-all for /public-key: %s\n", r.URL.Path)
 			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "keyid"})
@@ -107,6 +113,19 @@ func setupMultiHandlerTestServer(t *testing.T, handlers map[string]http.HandlerF
 	return server, client
 }
 
+// newTestAPIClient starts a test server for the handler, closed when the test ends,
+// and returns a client that sends every request to it
+func newTestAPIClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	ghClient := github.NewClient(newTestClient(baseURL))
+	ghClient.BaseURL = baseURL
+	return &Client{github: ghClient, ctx: context.Background(), opts: &ClientOptions{AuthMethod: AuthMethodPAT}}
+}
+
 func TestListOrgSecrets(t *testing.T) {
 	response := &github.Secrets{
 		Secrets: []*github.Secret{
@@ -135,46 +154,84 @@ func TestListOrgSecrets(t *testing.T) {
 }
 
 func TestListRepositoriesByProperty(t *testing.T) {
-	type repoStruct struct {
-		Name string `json:"name"`
+	propertyValues := func(name, value string) *RepositoryPropertyValues {
+		return &RepositoryPropertyValues{
+			RepositoryName: name,
+			Properties:     []*PropertyValue{{PropertyName: "team", Value: value}},
+		}
 	}
-
-	type repoResponse struct {
-		Repositories []repoStruct `json:"repositories"`
-		HasNextPage  bool         `json:"has_next_page"`
+	orgRepos := []*github.Repository{
+		{Name: github.String("repo1"), FullName: github.String("testorg/repo1"), Visibility: github.String("private")},
+		{Name: github.String("repo2"), FullName: github.String("testorg/repo2")},
+		{Name: github.String("repo3"), FullName: github.String("testorg/repo3")},
 	}
 
 	t.Run("basic", func(t *testing.T) {
-		propertyResponse := &repoResponse{
-			Repositories: []repoStruct{
-				{Name: "repo1"},
-				{Name: "repo3"},
+		var requests []string
+		handlers := map[string]http.HandlerFunc{
+			"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode([]*RepositoryPropertyValues{
+					propertyValues("repo1", "backend"),
+					propertyValues("repo3", "backend"),
+				})
+			},
+			"/orgs/testorg/repos": func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(orgRepos)
+			},
+			"/repos/testorg": func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				w.WriteHeader(http.StatusInternalServerError)
 			},
-			HasNextPage: false,
 		}
 
-		repos := map[string]*github.Repository{
-			"repo1": {Name: github.String("repo1"), FullName: github.String("testorg/repo1")},
-			"repo3": {Name: github.String("repo3"), FullName: github.String("testorg/repo3")},
+		server, client := setupMultiHandlerTestServer(t, handlers)
+		defer server.Close()
+
+		matchingRepos, err := client.ListRepositoriesByProperty("testorg", "team", "backend")
+		if err != nil {
+			t.Fatalf("ListRepositoriesByProperty returned error: %v", err)
 		}
 
+		if len(matchingRepos) != 2 || matchingRepos[0].GetName() != "repo1" || matchingRepos[1].GetName() != "repo3" {
+			t.Fatalf("ListRepositoriesByProperty = %v, want repo1 and repo3", matchingRepos)
+		}
+		if matchingRepos[0].GetVisibility() != "private" {
+			t.Errorf("repo1 visibility = %q, want the metadata from the organization listing", matchingRepos[0].GetVisibility())
+		}
+		if len(requests) != 2 {
+			t.Errorf("requests = %v, want one property listing and one repository listing", requests)
+		}
+	})
+
+	t.Run("with_pagination", func(t *testing.T) {
+		var serverURL string
 		handlers := map[string]http.HandlerFunc{
 			"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(propertyResponse)
-			},
-			"/repos/testorg/repo1": func(w http.ResponseWriter, r *http.Request) {
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(repos["repo1"])
+				if r.URL.Query().Get("page") != "2" {
+					next := fmt.Sprintf("%sorgs/testorg/properties/values?%s", serverURL, url.Values{
+						"repository_query": {r.URL.Query().Get("repository_query")},
+						"page":             {"2"},
+					}.Encode())
+					w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+					json.NewEncoder(w).Encode([]*RepositoryPropertyValues{propertyValues("repo1", "backend")})
+					return
+				}
+				json.NewEncoder(w).Encode([]*RepositoryPropertyValues{propertyValues("repo3", "backend")})
 			},
-			"/repos/testorg/repo3": func(w http.ResponseWriter, r *http.Request) {
+			"/orgs/testorg/repos": func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(repos["repo3"])
+				json.NewEncoder(w).Encode(orgRepos)
 			},
 		}
 
 		server, client := setupMultiHandlerTestServer(t, handlers)
 		defer server.Close()
+		serverURL = server.URL + "/"
 
 		matchingRepos, err := client.ListRepositoriesByProperty("testorg", "team", "backend")
 		if err != nil {
@@ -193,59 +250,56 @@ func TestListRepositoriesByProperty(t *testing.T) {
 		}
 	})
 
-	t.Run("with_pagination", func(t *testing.T) {
-		var pageNum int
-		responses := []*repoResponse{
-			{
-				Repositories: []repoStruct{{Name: "repo1"}},
-				HasNextPage:  true,
-			},
-			{
-				Repositories: []repoStruct{{Name: "repo3"}},
-				HasNextPage:  false,
-			},
-		}
-
-		repos := map[string]*github.Repository{
-			"repo1": {Name: github.String("repo1"), FullName: github.String("testorg/repo1")},
-			"repo3": {Name: github.String("repo3"), FullName: github.String("testorg/repo3")},
-		}
-
+	t.Run("escapes_query", func(t *testing.T) {
+		var query string
 		handlers := map[string]http.HandlerFunc{
 			"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query().Get("repository_query")
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(responses[pageNum])
-				if pageNum < len(responses)-1 {
-					pageNum++
-				}
+				json.NewEncoder(w).Encode([]*RepositoryPropertyValues{
+					propertyValues("repo1", "R&D ops"),
+					// Search matching is looser than equality
+					propertyValues("repo2", "R&D"),
+				})
 			},
-			"/repos/testorg/repo1": func(w http.ResponseWriter, r *http.Request) {
+			"/orgs/testorg/repos": func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(repos["repo1"])
-			},
-			"/repos/testorg/repo3": func(w http.ResponseWriter, r *http.Request) {
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(repos["repo3"])
+				json.NewEncoder(w).Encode(orgRepos)
 			},
 		}
 
 		server, client := setupMultiHandlerTestServer(t, handlers)
 		defer server.Close()
 
-		matchingRepos, err := client.ListRepositoriesByProperty("testorg", "team", "backend")
+		matchingRepos, err := client.ListRepositoriesByProperty("testorg", "team", "R&D ops")
 		if err != nil {
 			t.Fatalf("ListRepositoriesByProperty returned error: %v", err)
 		}
+		if want := `props.team:"R&D ops"`; query != want {
+			t.Errorf("repository_query = %q, want %q", query, want)
+		}
+		if len(matchingRepos) != 1 || matchingRepos[0].GetName() != "repo1" {
+			t.Errorf("ListRepositoriesByProperty = %v, want only repo1", matchingRepos)
+		}
+	})
 
-		if len(matchingRepos) != 2 {
-			t.Errorf("ListRepositoriesByProperty returned %d repos, want 2", len(matchingRepos))
+	t.Run("no_matches", func(t *testing.T) {
+		handlers := map[string]http.HandlerFunc{
+			"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, "[]")
+			},
+			"/orgs/testorg/repos": func(w http.ResponseWriter, r *http.Request) {
+				t.Error("listed organization repositories without any matches")
+			},
 		}
 
-		expectedMap := map[string]bool{"repo1": true, "repo3": true}
-		for _, repo := range matchingRepos {
-			if !expectedMap[repo.GetName()] {
-				t.Errorf("Got unexpected repository %s", repo.GetName())
-			}
+		server, client := setupMultiHandlerTestServer(t, handlers)
+		defer server.Close()
+
+		matchingRepos, err := client.ListRepositoriesByProperty("testorg", "team", "backend")
+		if err != nil || len(matchingRepos) != 0 {
+			t.Errorf("ListRepositoriesByProperty = %v, %v, want no repositories", matchingRepos, err)
 		}
 	})
 }
@@ -318,24 +372,12 @@ func TestListRepositoriesByPropertyErrors(t *testing.T) {
 	})
 
 	t.Run("repository_api_error", func(t *testing.T) {
-		type repoStruct struct {
-			Name string `json:"name"`
-		}
-
-		propertyResponse := struct {
-			Repositories []repoStruct `json:"repositories"`
-			HasNextPage  bool         `json:"has_next_page"`
-		}{
-			Repositories: []repoStruct{{Name: "repo1"}},
-			HasNextPage:  false,
-		}
-
 		handlers := map[string]http.HandlerFunc{
 			"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(propertyResponse)
+				fmt.Fprint(w, `[{"repository_name": "repo1", "properties": [{"property_name": "team", "value": "backend"}]}]`)
 			},
-			"/repos/testorg/": func(w http.ResponseWriter, r *http.Request) {
+			"/orgs/testorg/repos": func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusInternalServerError)
 			},
 		}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// GetOrgCodespacesPublicKey fetches the public key for encrypting organization Codespaces secrets
+func (c *Client) GetOrgCodespacesPublicKey(org string) (*SecretEncryption, error) {
+	return c.GetPublicKey(OrgSecretScope(SecretTypeCodespaces, org))
+}
+
+// GetRepoCodespacesPublicKey fetches the public key for encrypting repository Codespaces secrets
+func (c *Client) GetRepoCodespacesPublicKey(owner, repo string) (*SecretEncryption, error) {
+	return c.GetPublicKey(RepoSecretScope(SecretTypeCodespaces, owner, repo))
+}
+
+// GetUserCodespacesPublicKey fetches the public key for encrypting the authenticated user's Codespaces secrets
+func (c *Client) GetUserCodespacesPublicKey() (*SecretEncryption, error) {
+	return c.GetPublicKey(UserSecretScope())
+}
+
+// ListOrgCodespacesSecrets lists the Codespaces secrets of an organization
+func (c *Client) ListOrgCodespacesSecrets(org string) ([]*github.Secret, error) {
+	return collect(c.IterOrgCodespacesSecrets(org))
+}
+
+// IterOrgCodespacesSecrets streams organization Codespaces secrets, fetching pages as they are consumed
+func (c *Client) IterOrgCodespacesSecrets(org string) iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, fmt.Sprintf("orgs/%s/codespaces/secrets", org), "secrets", "organization Codespaces secrets")
+}
+
+// ListRepoCodespacesSecrets lists the Codespaces secrets of a repository
+func (c *Client) ListRepoCodespacesSecrets(owner, repo string) ([]*github.Secret, error) {
+	return collect(c.IterRepoCodespacesSecrets(owner, repo))
+}
+
+// IterRepoCodespacesSecrets streams repository Codespaces secrets, fetching pages as they are consumed
+func (c *Client) IterRepoCodespacesSecrets(owner, repo string) iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, fmt.Sprintf("repos/%s/%s/codespaces/secrets", owner, repo), "secrets", "repository Codespaces secrets")
+}
+
+// ListUserCodespacesSecrets lists the authenticated user's Codespaces secrets
+func (c *Client) ListUserCodespacesSecrets() ([]*github.Secret, error) {
+	return collect(c.IterUserCodespacesSecrets())
+}
+
+// IterUserCodespacesSecrets streams the authenticated user's Codespaces secrets, fetching pages as they are consumed
+func (c *Client) IterUserCodespacesSecrets() iter.Seq2[*github.Secret, error] {
+	return paginate[*github.Secret](c, "user/codespaces/secrets", "secrets", "user Codespaces secrets")
+}
+
+// CreateOrUpdateOrgCodespacesSecret creates or updates an organization Codespaces secret.
+// Visibility and selected repositories are only sent when set.
+func (c *Client) CreateOrUpdateOrgCodespacesSecret(org string, secret *github.EncryptedSecret) error {
+	return c.CreateOrUpdateSecret(OrgSecretScope(SecretTypeCodespaces, org), secret)
+}
+
+// CreateOrUpdateRepoCodespacesSecret creates or updates a repository Codespaces secret
+func (c *Client) CreateOrUpdateRepoCodespacesSecret(owner, repo string, secret *github.EncryptedSecret) error {
+	return c.CreateOrUpdateSecret(RepoSecretScope(SecretTypeCodespaces, owner, repo), secret)
+}
+
+// CreateOrUpdateUserCodespacesSecret creates or updates one of the authenticated user's
+// Codespaces secrets. User secrets have no visibility; SelectedRepositoryIDs lists the
+// repositories whose codespaces can use the secret.
+func (c *Client) CreateOrUpdateUserCodespacesSecret(secret *github.EncryptedSecret) error {
+	return c.CreateOrUpdateSecret(UserSecretScope(), secret)
+}
+
+// DeleteOrgCodespacesSecret deletes an organization Codespaces secret
+func (c *Client) DeleteOrgCodespacesSecret(org, name string) error {
+	return c.DeleteSecret(OrgSecretScope(SecretTypeCodespaces, org), name)
+}
+
+// DeleteRepoCodespacesSecret deletes a repository Codespaces secret
+func (c *Client) DeleteRepoCodespacesSecret(owner, repo, name string) error {
+	return c.DeleteSecret(RepoSecretScope(SecretTypeCodespaces, owner, repo), name)
+}
+
+// DeleteUserCodespacesSecret deletes one of the authenticated user's Codespaces secrets
+func (c *Client) DeleteUserCodespacesSecret(name string) error {
+	return c.DeleteSecret(UserSecretScope(), name)
+}
+
+// ListSelectedReposForOrgCodespacesSecret returns the repositories that can access an
+// organization Codespaces secret whose visibility is selected
+func (c *Client) ListSelectedReposForOrgCodespacesSecret(org, name string) ([]*github.Repository, error) {
+	return collect(paginate[*github.Repository](c, fmt.Sprintf("orgs/%s/codespaces/secrets/%s/repositories", org, name), "repositories", "selected repositories"))
+}
+
+// SetSelectedReposForOrgCodespacesSecret replaces the repositories that can access an organization Codespaces secret
+func (c *Client) SetSelectedReposForOrgCodespacesSecret(org, name string, repoIDs []int64) error {
+	return c.setSelectedRepos(fmt.Sprintf("orgs/%s/codespaces/secrets/%s/repositories", org, name), repoIDs)
+}
+
+// AddSelectedRepoToOrgCodespacesSecret gives a repository access to an organization Codespaces secret
+func (c *Client) AddSelectedRepoToOrgCodespacesSecret(org, name string, repoID int64) error {
+	return c.updateSelectedRepo("PUT", fmt.Sprintf("orgs/%s/codespaces/secrets/%s/repositories/%d", org, name, repoID))
+}
+
+// RemoveSelectedRepoFromOrgCodespacesSecret removes a repository's access to an organization Codespaces secret
+func (c *Client) RemoveSelectedRepoFromOrgCodespacesSecret(org, name string, repoID int64) error {
+	return c.updateSelectedRepo("DELETE", fmt.Sprintf("orgs/%s/codespaces/secrets/%s/repositories/%d", org, name, repoID))
+}
+
+// ListSelectedReposForUserCodespacesSecret returns the repositories whose codespaces can
+// use one of the authenticated user's Codespaces secrets
+func (c *Client) ListSelectedReposForUserCodespacesSecret(name string) ([]*github.Repository, error) {
+	return collect(paginate[*github.Repository](c, fmt.Sprintf("user/codespaces/secrets/%s/repositories", name), "repositories", "selected repositories"))
+}
+
+// SetSelectedReposForUserCodespacesSecret replaces the repositories that can use a user Codespaces secret
+func (c *Client) SetSelectedReposForUserCodespacesSecret(name string, repoIDs []int64) error {
+	return c.setSelectedRepos(fmt.Sprintf("user/codespaces/secrets/%s/repositories", name), repoIDs)
+}
+
+// AddSelectedRepoToUserCodespacesSecret gives a repository access to a user Codespaces secret
+func (c *Client) AddSelectedRepoToUserCodespacesSecret(name string, repoID int64) error {
+	return c.updateSelectedRepo("PUT", fmt.Sprintf("user/codespaces/secrets/%s/repositories/%d", name, repoID))
+}
+
+// RemoveSelectedRepoFromUserCodespacesSecret removes a repository's access to a user Codespaces secret
+func (c *Client) RemoveSelectedRepoFromUserCodespacesSecret(name string, repoID int64) error {
+	return c.updateSelectedRepo("DELETE", fmt.Sprintf("user/codespaces/secrets/%s/repositories/%d", name, repoID))
+}
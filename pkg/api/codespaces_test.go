@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func TestCodespacesSecrets(t *testing.T) {
+	var requests []string
+	bodies := make(map[string]map[string]any)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "PUT" {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies[r.URL.Path] = body
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/codespaces/secrets/public-key": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "org-key"})
+		},
+		"/repos/testorg/repo/codespaces/secrets/public-key": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "repo-key"})
+		},
+		"/user/codespaces/secrets/public-key": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "user-key"})
+		},
+		"/user/codespaces/secrets": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" && r.URL.Path == "/user/codespaces/secrets" {
+				json.NewEncoder(w).Encode(&github.Secrets{TotalCount: 1, Secrets: []*github.Secret{{Name: "NPM_TOKEN", Visibility: "selected"}}})
+				return
+			}
+			record(w, r)
+		},
+		"/orgs/testorg/codespaces/secrets/NPM_TOKEN":       record,
+		"/repos/testorg/repo/codespaces/secrets/NPM_TOKEN": record,
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	secret := &github.EncryptedSecret{Name: "NPM_TOKEN", EncryptedValue: "value", Visibility: VisibilitySelected, SelectedRepositoryIDs: github.SelectedRepoIDs{1}}
+	if err := client.CreateOrUpdateOrgCodespacesSecret("testorg", secret); err != nil {
+		t.Fatalf("CreateOrUpdateOrgCodespacesSecret returned error: %v", err)
+	}
+	if err := client.CreateOrUpdateRepoCodespacesSecret("testorg", "repo", secret); err != nil {
+		t.Fatalf("CreateOrUpdateRepoCodespacesSecret returned error: %v", err)
+	}
+	if err := client.CreateOrUpdateUserCodespacesSecret(secret); err != nil {
+		t.Fatalf("CreateOrUpdateUserCodespacesSecret returned error: %v", err)
+	}
+
+	org := bodies["/orgs/testorg/codespaces/secrets/NPM_TOKEN"]
+	if org["key_id"] != "org-key" || org["visibility"] != "selected" || org["encrypted_value"] == "value" {
+		t.Errorf("organization body = %v, want encrypted value, org key and visibility", org)
+	}
+	repo := bodies["/repos/testorg/repo/codespaces/secrets/NPM_TOKEN"]
+	if repo["key_id"] != "repo-key" || repo["visibility"] != nil || repo["selected_repository_ids"] != nil {
+		t.Errorf("repository body = %v, want only the encrypted value and repo key", repo)
+	}
+	user := bodies["/user/codespaces/secrets/NPM_TOKEN"]
+	if user["key_id"] != "user-key" || user["visibility"] != nil || user["selected_repository_ids"] == nil {
+		t.Errorf("user body = %v, want user key and repositories without visibility", user)
+	}
+
+	secrets, err := client.ListUserCodespacesSecrets()
+	if err != nil {
+		t.Fatalf("ListUserCodespacesSecrets returned error: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Visibility != "selected" {
+		t.Errorf("ListUserCodespacesSecrets = %v, want NPM_TOKEN", secrets)
+	}
+
+	for _, del := range []func() error{
+		func() error { return client.DeleteOrgCodespacesSecret("testorg", "NPM_TOKEN") },
+		func() error { return client.DeleteRepoCodespacesSecret("testorg", "repo", "NPM_TOKEN") },
+		func() error { return client.DeleteUserCodespacesSecret("NPM_TOKEN") },
+	} {
+		if err := del(); err != nil {
+			t.Fatalf("delete returned error: %v", err)
+		}
+	}
+	deletes := requests[len(requests)-3:]
+	want := []string{
+		"DELETE /orgs/testorg/codespaces/secrets/NPM_TOKEN",
+		"DELETE /repos/testorg/repo/codespaces/secrets/NPM_TOKEN",
+		"DELETE /user/codespaces/secrets/NPM_TOKEN",
+	}
+	for i := range want {
+		if deletes[i] != want[i] {
+			t.Errorf("request %d = %s, want %s", i, deletes[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// Reviewer types accepted by environment protection rules
+const (
+	ReviewerUser = "User"
+	ReviewerTeam = "Team"
+)
+
+// Deployment branch policy types
+const (
+	BranchPolicyBranch = "branch"
+	BranchPolicyTag    = "tag"
+)
+
+// EnvironmentReviewer is a user or team that must approve deployments to an environment.
+// Name is the user's login or the team's slug; it is used to look up the ID when the ID is not set.
+type EnvironmentReviewer struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// DeploymentBranchPolicy is a branch or tag name pattern that may deploy to an
+// environment with custom deployment branch policies
+type DeploymentBranchPolicy struct {
+	ID   int64  `json:"id,omitempty"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// EnvironmentProtection holds the protection rules and deployment branch policy of an
+// environment. A nil DeploymentBranchPolicy lets every branch deploy; BranchPolicies
+// only apply when the policy has custom branch policies enabled.
+type EnvironmentProtection struct {
+	WaitTimer              int                       `json:"wait_timer"`
+	Reviewers              []EnvironmentReviewer     `json:"reviewers,omitempty"`
+	DeploymentBranchPolicy *github.BranchPolicy      `json:"deployment_branch_policy,omitempty"`
+	BranchPolicies         []*DeploymentBranchPolicy `json:"branch_policies,omitempty"`
+}
+
+// customBranchPolicies reports whether the protection restricts deployments to the
+// branch policies it lists
+func (p *EnvironmentProtection) customBranchPolicies() bool {
+	return p.DeploymentBranchPolicy != nil && p.DeploymentBranchPolicy.GetCustomBranchPolicies()
+}
+
+// GetEnvironmentProtection reads the protection rules of an environment, including its
+// custom deployment branch policies
+func (c *Client) GetEnvironmentProtection(owner, repo, name string) (*EnvironmentProtection, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.github.NewRequest("GET", environmentURL(owner, repo, name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var environment struct {
+		ProtectionRules []struct {
+			Type      string `json:"type"`
+			WaitTimer int    `json:"wait_timer"`
+			Reviewers []struct {
+				Type     string `json:"type"`
+				Reviewer struct {
+					ID    int64  `json:"id"`
+					Login string `json:"login"`
+					Slug  string `json:"slug"`
+				} `json:"reviewer"`
+			} `json:"reviewers"`
+		} `json:"protection_rules"`
+		DeploymentBranchPolicy *github.BranchPolicy `json:"deployment_branch_policy"`
+	}
+	_, err = c.github.Do(c.ctx, req, &environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment %s: %w", name, err)
+	}
+
+	protection := &EnvironmentProtection{DeploymentBranchPolicy: environment.DeploymentBranchPolicy}
+	for _, rule := range environment.ProtectionRules {
+		switch rule.Type {
+		case "wait_timer":
+			protection.WaitTimer = rule.WaitTimer
+		case "required_reviewers":
+			for _, r := range rule.Reviewers {
+				reviewer := EnvironmentReviewer{Type: r.Type, ID: r.Reviewer.ID, Name: r.Reviewer.Login}
+				if r.Type == ReviewerTeam {
+					reviewer.Name = r.Reviewer.Slug
+				}
+				protection.Reviewers = append(protection.Reviewers, reviewer)
+			}
+		}
+	}
+
+	if protection.customBranchPolicies() {
+		protection.BranchPolicies, err = c.ListDeploymentBranchPolicies(owner, repo, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return protection, nil
+}
+
+// SetEnvironmentProtection replaces the protection rules of an environment, creating it
+// when it does not exist. With custom branch policies enabled, the environment's branch
+// policies are made to match BranchPolicies: missing ones are created and others deleted.
+func (c *Client) SetEnvironmentProtection(owner, repo, name string, protection *EnvironmentProtection) error {
+	reviewers := make([]*github.EnvReviewers, 0, len(protection.Reviewers))
+	for _, reviewer := range protection.Reviewers {
+		id, err := c.reviewerID(owner, reviewer)
+		if err != nil {
+			return err
+		}
+		reviewers = append(reviewers, &github.EnvReviewers{Type: github.String(reviewer.Type), ID: github.Int64(id)})
+	}
+
+	settings := &github.CreateUpdateEnvironment{
+		WaitTimer:              github.Int(protection.WaitTimer),
+		Reviewers:              reviewers,
+		DeploymentBranchPolicy: protection.DeploymentBranchPolicy,
+	}
+	if _, err := c.CreateOrUpdateEnvironment(owner, repo, name, settings); err != nil {
+		return err
+	}
+
+	if !protection.customBranchPolicies() {
+		return nil
+	}
+	return c.syncDeploymentBranchPolicies(owner, repo, name, protection.BranchPolicies)
+}
+
+// ListDeploymentBranchPolicies lists the custom deployment branch policies of an environment
+func (c *Client) ListDeploymentBranchPolicies(owner, repo, environment string) ([]*DeploymentBranchPolicy, error) {
+	return collect(c.IterDeploymentBranchPolicies(owner, repo, environment))
+}
+
+// IterDeploymentBranchPolicies streams deployment branch policies, fetching pages as they are consumed
+func (c *Client) IterDeploymentBranchPolicies(owner, repo, environment string) iter.Seq2[*DeploymentBranchPolicy, error] {
+	return paginate[*DeploymentBranchPolicy](c, environmentURL(owner, repo, environment)+"/deployment-branch-policies", "branch_policies", "deployment branch policies")
+}
+
+// CreateDeploymentBranchPolicy adds a branch or tag pattern to an environment with
+// custom deployment branch policies. An empty type means a branch pattern.
+func (c *Client) CreateDeploymentBranchPolicy(owner, repo, environment string, policy *DeploymentBranchPolicy) (*DeploymentBranchPolicy, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	body := &DeploymentBranchPolicy{Name: policy.Name, Type: policy.Type}
+	req, err := c.github.NewRequest("POST", environmentURL(owner, repo, environment)+"/deployment-branch-policies", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	created := &DeploymentBranchPolicy{}
+	_, err = c.github.Do(c.ctx, req, created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deployment branch policy %s: %w", policy.Name, err)
+	}
+	return created, nil
+}
+
+// DeleteDeploymentBranchPolicy removes a deployment branch policy from an environment
+func (c *Client) DeleteDeploymentBranchPolicy(owner, repo, environment string, id int64) error {
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	req, err := c.github.NewRequest("DELETE", fmt.Sprintf("%s/deployment-branch-policies/%d", environmentURL(owner, repo, environment), id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	_, err = c.github.Do(c.ctx, req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete deployment branch policy: %w", err)
+	}
+	return nil
+}
+
+// syncDeploymentBranchPolicies creates the wanted policies an environment lacks and
+// deletes the ones that are not wanted, matching policies by name and type
+func (c *Client) syncDeploymentBranchPolicies(owner, repo, environment string, wanted []*DeploymentBranchPolicy) error {
+	existing, err := c.ListDeploymentBranchPolicies(owner, repo, environment)
+	if err != nil {
+		return err
+	}
+
+	key := func(p *DeploymentBranchPolicy) string {
+		if p.Type == "" {
+			return BranchPolicyBranch + ":" + p.Name
+		}
+		return p.Type + ":" + p.Name
+	}
+
+	current := make(map[string]*DeploymentBranchPolicy, len(existing))
+	for _, policy := range existing {
+		current[key(policy)] = policy
+	}
+
+	for _, policy := range wanted {
+		if _, ok := current[key(policy)]; ok {
+			delete(current, key(policy))
+			continue
+		}
+		if _, err := c.CreateDeploymentBranchPolicy(owner, repo, environment, policy); err != nil {
+			return err
+		}
+	}
+
+	for _, policy := range existing {
+		if _, stale := current[key(policy)]; stale {
+			if err := c.DeleteDeploymentBranchPolicy(owner, repo, environment, policy.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reviewerID returns the ID of a reviewer, looking up users by login and teams by slug
+// within the repository owner's organization when the ID is not set
+func (c *Client) reviewerID(owner string, reviewer EnvironmentReviewer) (int64, error) {
+	if reviewer.ID != 0 {
+		return reviewer.ID, nil
+	}
+	if reviewer.Name == "" {
+		return 0, fmt.Errorf("reviewer needs an id or a name")
+	}
+
+	var url string
+	switch reviewer.Type {
+	case ReviewerUser:
+		url = fmt.Sprintf("users/%s", reviewer.Name)
+	case ReviewerTeam:
+		url = fmt.Sprintf("orgs/%s/teams/%s", owner, reviewer.Name)
+	default:
+		return 0, fmt.Errorf("unsupported reviewer type: %s (expected User or Team)", reviewer.Type)
+	}
+
+	if err := c.ensureValidToken(); err != nil {
+		return 0, err
+	}
+	req, err := c.github.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var found struct {
+		ID int64 `json:"id"`
+	}
+	_, err = c.github.Do(c.ctx, req, &found)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up %s reviewer %s: %w", reviewer.Type, reviewer.Name, err)
+	}
+	return found.ID, nil
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func TestGetEnvironmentProtection(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"/repos/testorg/repo/environments/prod": func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/deployment-branch-policies") {
+				w.Write([]byte(`{"total_count": 2, "branch_policies": [{"id": 1, "name": "main", "type": "branch"}, {"id": 2, "name": "v*", "type": "tag"}]}`))
+				return
+			}
+			w.Write([]byte(`{
+				"name": "prod",
+				"protection_rules": [
+					{"id": 1, "type": "wait_timer", "wait_timer": 30},
+					{"id": 2, "type": "required_reviewers", "reviewers": [
+						{"type": "User", "reviewer": {"id": 10, "login": "octocat"}},
+						{"type": "Team", "reviewer": {"id": 20, "slug": "platform"}}
+					]},
+					{"id": 3, "type": "branch_policy"}
+				],
+				"deployment_branch_policy": {"protected_branches": false, "custom_branch_policies": true}
+			}`))
+		},
+	}
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	protection, err := client.GetEnvironmentProtection("testorg", "repo", "prod")
+	if err != nil {
+		t.Fatalf("GetEnvironmentProtection returned error: %v", err)
+	}
+
+	if protection.WaitTimer != 30 {
+		t.Errorf("WaitTimer = %d, want 30", protection.WaitTimer)
+	}
+	wantReviewers := []EnvironmentReviewer{
+		{Type: ReviewerUser, ID: 10, Name: "octocat"},
+		{Type: ReviewerTeam, ID: 20, Name: "platform"},
+	}
+	if len(protection.Reviewers) != len(wantReviewers) {
+		t.Fatalf("Reviewers = %v, want %v", protection.Reviewers, wantReviewers)
+	}
+	for i, want := range wantReviewers {
+		if protection.Reviewers[i] != want {
+			t.Errorf("Reviewers[%d] = %v, want %v", i, protection.Reviewers[i], want)
+		}
+	}
+	if len(protection.BranchPolicies) != 2 || protection.BranchPolicies[1].Type != BranchPolicyTag {
+		t.Errorf("BranchPolicies = %v, want main and v* tag", protection.BranchPolicies)
+	}
+}
+
+func TestGetEnvironmentProtectionAllBranches(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"/repos/testorg/repo/environments/dev": func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/deployment-branch-policies") {
+				t.Error("branch policies listed for an environment without custom branch policies")
+			}
+			w.Write([]byte(`{"name": "dev", "protection_rules": []}`))
+		},
+	}
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	protection, err := client.GetEnvironmentProtection("testorg", "repo", "dev")
+	if err != nil {
+		t.Fatalf("GetEnvironmentProtection returned error: %v", err)
+	}
+	if protection.WaitTimer != 0 || protection.Reviewers != nil || protection.DeploymentBranchPolicy != nil {
+		t.Errorf("GetEnvironmentProtection = %+v, want no protection", protection)
+	}
+}
+
+func TestSetEnvironmentProtection(t *testing.T) {
+	var requests []string
+	var environmentBody map[string]any
+	var createdPolicies []DeploymentBranchPolicy
+
+	handlers := map[string]http.HandlerFunc{
+		"/users/octocat": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"id": 10, "login": "octocat"}`))
+		},
+		"/orgs/testorg/teams/platform": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"id": 20, "slug": "platform"}`))
+		},
+		"/repos/testorg/repo/environments/prod": func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch {
+			case r.URL.Path == "/repos/testorg/repo/environments/prod" && r.Method == "PUT":
+				json.NewDecoder(r.Body).Decode(&environmentBody)
+				w.Write([]byte(`{"name": "prod"}`))
+			case strings.HasSuffix(r.URL.Path, "/deployment-branch-policies") && r.Method == "GET":
+				w.Write([]byte(`{"total_count": 2, "branch_policies": [{"id": 1, "name": "main", "type": "branch"}, {"id": 2, "name": "develop", "type": "branch"}]}`))
+			case strings.HasSuffix(r.URL.Path, "/deployment-branch-policies") && r.Method == "POST":
+				var policy DeploymentBranchPolicy
+				json.NewDecoder(r.Body).Decode(&policy)
+				createdPolicies = append(createdPolicies, policy)
+				policy.ID = 3
+				json.NewEncoder(w).Encode(policy)
+			case r.Method == "DELETE":
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		},
+	}
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	err := client.SetEnvironmentProtection("testorg", "repo", "prod", &EnvironmentProtection{
+		WaitTimer: 15,
+		Reviewers: []EnvironmentReviewer{
+			{Type: ReviewerUser, Name: "octocat"},
+			{Type: ReviewerTeam, Name: "platform"},
+			{Type: ReviewerUser, ID: 30},
+		},
+		DeploymentBranchPolicy: &github.BranchPolicy{ProtectedBranches: github.Bool(false), CustomBranchPolicies: github.Bool(true)},
+		BranchPolicies: []*DeploymentBranchPolicy{
+			{Name: "main"},
+			{Name: "v*", Type: BranchPolicyTag},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetEnvironmentProtection returned error: %v", err)
+	}
+
+	if environmentBody["wait_timer"] != float64(15) {
+		t.Errorf("wait_timer = %v, want 15", environmentBody["wait_timer"])
+	}
+	reviewers, _ := json.Marshal(environmentBody["reviewers"])
+	if string(reviewers) != `[{"id":10,"type":"User"},{"id":20,"type":"Team"},{"id":30,"type":"User"}]` {
+		t.Errorf("reviewers = %s, want looked-up user and team IDs", reviewers)
+	}
+
+	if len(createdPolicies) != 1 || createdPolicies[0].Name != "v*" || createdPolicies[0].Type != BranchPolicyTag {
+		t.Errorf("created policies = %v, want only the v* tag", createdPolicies)
+	}
+	if last := requests[len(requests)-1]; last != "DELETE /repos/testorg/repo/environments/prod/deployment-branch-policies/2" {
+		t.Errorf("last request = %s, want deletion of the develop policy", last)
+	}
+}
+
+func TestSetEnvironmentProtectionUnknownReviewerType(t *testing.T) {
+	server, client := setupMultiHandlerTestServer(t, map[string]http.HandlerFunc{})
+	defer server.Close()
+
+	err := client.SetEnvironmentProtection("testorg", "repo", "prod", &EnvironmentProtection{
+		Reviewers: []EnvironmentReviewer{{Type: "Bot", Name: "dependabot"}},
+	})
+	if err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
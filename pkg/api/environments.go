@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"iter"
+	"log"
+	"net/url"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// ListEnvironments lists the deployment environments of a repository
+func (c *Client) ListEnvironments(owner, repo string) ([]*github.Environment, error) {
+	return collect(c.IterEnvironments(owner, repo))
+}
+
+// IterEnvironments streams repository environments, fetching pages as they are consumed
+func (c *Client) IterEnvironments(owner, repo string) iter.Seq2[*github.Environment, error] {
+	return paginate[*github.Environment](c, fmt.Sprintf("repos/%s/%s/environments", owner, repo), "environments", "environments")
+}
+
+// GetEnvironment fetches a single repository environment
+func (c *Client) GetEnvironment(owner, repo, name string) (*github.Environment, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.github.NewRequest("GET", environmentURL(owner, repo, name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	environment := &github.Environment{}
+	_, err = c.github.Do(c.ctx, req, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment %s: %w", name, err)
+	}
+	return environment, nil
+}
+
+// CreateOrUpdateEnvironment creates an environment or updates an existing one. When
+// settings is nil no body is sent, so an existing environment keeps its protection rules.
+func (c *Client) CreateOrUpdateEnvironment(owner, repo, name string, settings *github.CreateUpdateEnvironment) (*github.Environment, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	var body interface{}
+	if settings != nil {
+		body = settings
+	}
+	req, err := c.github.NewRequest("PUT", environmentURL(owner, repo, name), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	environment := &github.Environment{}
+	_, err = c.github.Do(c.ctx, req, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create/update environment %s: %w", name, err)
+	}
+	return environment, nil
+}
+
+// EnsureEnvironment creates an environment when it does not exist yet and reports
+// whether it was created. Existing environments are left unchanged.
+func (c *Client) EnsureEnvironment(owner, repo, name string) (bool, error) {
+	_, err := c.GetEnvironment(owner, repo, name)
+	if err == nil {
+		return false, nil
+	}
+	if !IsNotFound(err) {
+		return false, err
+	}
+
+	if Verbose {
+		log.Printf("Environment %s not found in %s/%s, creating it", name, owner, repo)
+	}
+	if _, err := c.CreateOrUpdateEnvironment(owner, repo, name, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteEnvironment deletes an environment along with its secrets and variables
+func (c *Client) DeleteEnvironment(owner, repo, name string) error {
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	req, err := c.github.NewRequest("DELETE", environmentURL(owner, repo, name), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	_, err = c.github.Do(c.ctx, req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete environment %s: %w", name, err)
+	}
+	return nil
+}
+
+// environmentURL returns the API path of an environment. Environment names may contain
+// spaces and other characters that must be escaped.
+func environmentURL(owner, repo, name string) string {
+	return fmt.Sprintf("repos/%s/%s/environments/%s", owner, repo, url.PathEscape(name))
+}
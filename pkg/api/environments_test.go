@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// fakeEnvironmentServer stores the environments of testorg/repo and records the
+// requests and bodies it receives
+type fakeEnvironmentServer struct {
+	environments map[string]*github.Environment
+	requests     []string
+	bodies       map[string]string
+}
+
+func (f *fakeEnvironmentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	const collection = "/repos/testorg/repo/environments"
+	if r.URL.Path == collection && r.Method == "GET" {
+		var environments []*github.Environment
+		for _, env := range f.environments {
+			environments = append(environments, env)
+		}
+		json.NewEncoder(w).Encode(&github.EnvResponse{TotalCount: github.Int(len(environments)), Environments: environments})
+		return
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, collection+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		env, exists := f.environments[name]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+			return
+		}
+		json.NewEncoder(w).Encode(env)
+	case "PUT":
+		body, _ := io.ReadAll(r.Body)
+		f.bodies[name] = string(body)
+		env := &github.Environment{Name: github.String(name)}
+		f.environments[name] = env
+		json.NewEncoder(w).Encode(env)
+	case "DELETE":
+		delete(f.environments, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func setupFakeEnvironmentServer(t *testing.T, names ...string) (*fakeEnvironmentServer, *Client) {
+	t.Helper()
+	fake := &fakeEnvironmentServer{environments: make(map[string]*github.Environment), bodies: make(map[string]string)}
+	for _, name := range names {
+		fake.environments[name] = &github.Environment{Name: github.String(name)}
+	}
+	return fake, newTestAPIClient(t, fake)
+}
+
+func TestEnvironmentLifecycle(t *testing.T) {
+	fake, client := setupFakeEnvironmentServer(t, "staging")
+
+	environments, err := client.ListEnvironments("testorg", "repo")
+	if err != nil {
+		t.Fatalf("ListEnvironments returned error: %v", err)
+	}
+	if len(environments) != 1 || environments[0].GetName() != "staging" {
+		t.Errorf("ListEnvironments = %v, want staging", environments)
+	}
+
+	env, err := client.CreateOrUpdateEnvironment("testorg", "repo", "release candidate", nil)
+	if err != nil {
+		t.Fatalf("CreateOrUpdateEnvironment returned error: %v", err)
+	}
+	if env.GetName() != "release candidate" {
+		t.Errorf("CreateOrUpdateEnvironment returned %q, want release candidate", env.GetName())
+	}
+	if body := fake.bodies["release candidate"]; body != "" {
+		t.Errorf("CreateOrUpdateEnvironment sent body %q, want none", body)
+	}
+
+	if err := client.DeleteEnvironment("testorg", "repo", "staging"); err != nil {
+		t.Fatalf("DeleteEnvironment returned error: %v", err)
+	}
+	if _, exists := fake.environments["staging"]; exists {
+		t.Error("DeleteEnvironment did not delete staging")
+	}
+
+	if _, err := client.GetEnvironment("testorg", "repo", "staging"); !IsNotFound(err) {
+		t.Errorf("GetEnvironment error = %v, want not found", err)
+	}
+}
+
+func TestEnsureEnvironment(t *testing.T) {
+	fake, client := setupFakeEnvironmentServer(t, "staging")
+
+	created, err := client.EnsureEnvironment("testorg", "repo", "staging")
+	if err != nil {
+		t.Fatalf("EnsureEnvironment returned error: %v", err)
+	}
+	if created {
+		t.Error("EnsureEnvironment created an existing environment")
+	}
+
+	created, err = client.EnsureEnvironment("testorg", "repo", "prod")
+	if err != nil {
+		t.Fatalf("EnsureEnvironment returned error: %v", err)
+	}
+	if !created {
+		t.Error("EnsureEnvironment did not report creating prod")
+	}
+
+	want := []string{
+		"GET /repos/testorg/repo/environments/staging",
+		"GET /repos/testorg/repo/environments/prod",
+		"PUT /repos/testorg/repo/environments/prod",
+	}
+	if strings.Join(fake.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests =\n%s\nwant\n%s", strings.Join(fake.requests, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEnsureEnvironmentError(t *testing.T) {
+	client := newTestAPIClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	if _, err := client.EnsureEnvironment("testorg", "repo", "prod"); err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cli/go-gh/pkg/auth"
+	"github.com/google/go-github/v45/github"
+)
+
+// DefaultHost is the hostname of GitHub.com
+const DefaultHost = "github.com"
+
+// resolveHost returns host, or when it is empty the host gh would use: GH_HOST or the
+// only host gh is logged in to, falling back to github.com
+func resolveHost(host string) string {
+	if host != "" {
+		return host
+	}
+	host, _ = auth.DefaultHost()
+	return host
+}
+
+// tokenHost returns the hostname gh stores credentials under for a host or API base URL
+func tokenHost(host string) string {
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Hostname()
+		}
+	}
+	host = strings.ToLower(host)
+	if host == "api.github.com" {
+		return DefaultHost
+	}
+	if strings.HasPrefix(host, "api.") && strings.HasSuffix(host, ".ghe.com") {
+		return strings.TrimPrefix(host, "api.")
+	}
+	return host
+}
+
+// APIURLs returns the REST API and upload base URLs of a GitHub host: github.com,
+// a GHE.com data residency subdomain such as octocorp.ghe.com, or a GitHub Enterprise
+// Server hostname. A host given as a URL is used as the base URL of both.
+func APIURLs(host string) (*url.URL, *url.URL, error) {
+	if strings.Contains(host, "://") {
+		baseURL, err := url.Parse(host)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid API base URL %s: %w", host, err)
+		}
+		if baseURL.Host == "" {
+			return nil, nil, fmt.Errorf("invalid API base URL %s: missing host", host)
+		}
+		if !strings.HasSuffix(baseURL.Path, "/") {
+			baseURL.Path += "/"
+		}
+		return baseURL, baseURL, nil
+	}
+
+	host = tokenHost(host)
+	var base, upload string
+	switch {
+	case host == "" || host == DefaultHost:
+		base, upload = "https://api.github.com/", "https://uploads.github.com/"
+	case strings.HasSuffix(host, ".ghe.com"):
+		base, upload = fmt.Sprintf("https://api.%s/", host), fmt.Sprintf("https://uploads.%s/", host)
+	default:
+		base, upload = fmt.Sprintf("https://%s/api/v3/", host), fmt.Sprintf("https://%s/api/uploads/", host)
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid host %s: %w", host, err)
+	}
+	uploadURL, err := url.Parse(upload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid host %s: %w", host, err)
+	}
+	return baseURL, uploadURL, nil
+}
+
+// newGitHubClient returns a go-github client sending requests through httpClient to
+// the API of host
+func newGitHubClient(httpClient *http.Client, host string) (*github.Client, error) {
+	baseURL, uploadURL, err := APIURLs(host)
+	if err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(httpClient)
+	client.BaseURL = baseURL
+	client.UploadURL = uploadURL
+	return client, nil
+}
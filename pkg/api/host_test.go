@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func TestAPIURLs(t *testing.T) {
+	tests := []struct {
+		host       string
+		wantBase   string
+		wantUpload string
+		wantToken  string
+	}{
+		{"", "https://api.github.com/", "https://uploads.github.com/", ""},
+		{"github.com", "https://api.github.com/", "https://uploads.github.com/", "github.com"},
+		{"GitHub.com", "https://api.github.com/", "https://uploads.github.com/", "github.com"},
+		{"api.github.com", "https://api.github.com/", "https://uploads.github.com/", "github.com"},
+		{"octocorp.ghe.com", "https://api.octocorp.ghe.com/", "https://uploads.octocorp.ghe.com/", "octocorp.ghe.com"},
+		{"api.octocorp.ghe.com", "https://api.octocorp.ghe.com/", "https://uploads.octocorp.ghe.com/", "octocorp.ghe.com"},
+		{"github.example.com", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/", "github.example.com"},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/v3/", "https://github.example.com/api/v3/", "github.example.com"},
+		{"http://localhost:8080/", "http://localhost:8080/", "http://localhost:8080/", "localhost"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.host, func(t *testing.T) {
+			base, upload, err := APIURLs(tc.host)
+			if err != nil {
+				t.Fatalf("APIURLs(%q) returned error: %v", tc.host, err)
+			}
+			if base.String() != tc.wantBase || upload.String() != tc.wantUpload {
+				t.Errorf("APIURLs(%q) = %s, %s, want %s, %s", tc.host, base, upload, tc.wantBase, tc.wantUpload)
+			}
+			if got := tokenHost(tc.host); got != tc.wantToken {
+				t.Errorf("tokenHost(%q) = %q, want %q", tc.host, got, tc.wantToken)
+			}
+		})
+	}
+}
+
+func TestAPIURLsInvalid(t *testing.T) {
+	for _, host := range []string{"https://", "https://bad host/"} {
+		if _, _, err := APIURLs(host); err == nil {
+			t.Errorf("APIURLs(%q) returned no error", host)
+		}
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	t.Setenv("GH_HOST", "github.example.com")
+
+	if got := resolveHost(""); got != "github.example.com" {
+		t.Errorf("resolveHost(\"\") = %q, want GH_HOST", got)
+	}
+	if got := resolveHost("octocorp.ghe.com"); got != "octocorp.ghe.com" {
+		t.Errorf("resolveHost(octocorp.ghe.com) = %q, want the given host", got)
+	}
+}
+
+func TestEnterpriseHostRequests(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/public-key") {
+			w.Write([]byte(`{"key_id": "keyid", "key": "` + valid32ByteKey + `"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ghClient, err := newGitHubClient(server.Client(), server.URL+"/api/v3")
+	if err != nil {
+		t.Fatalf("newGitHubClient returned error: %v", err)
+	}
+	client := &Client{github: ghClient, ctx: context.Background(), opts: &ClientOptions{AuthMethod: AuthMethodPAT}}
+
+	if err := client.CreateOrUpdateRepoCodespacesSecret("testorg", "repo", &github.EncryptedSecret{Name: "NPM_TOKEN", EncryptedValue: "value"}); err != nil {
+		t.Fatalf("CreateOrUpdateRepoCodespacesSecret returned error: %v", err)
+	}
+
+	want := []string{
+		"GET /api/v3/repos/testorg/repo/codespaces/secrets/public-key",
+		"PUT /api/v3/repos/testorg/repo/codespaces/secrets/NPM_TOKEN",
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests =\n%s\nwant\n%s", strings.Join(paths, "\n"), strings.Join(want, "\n"))
+	}
+}
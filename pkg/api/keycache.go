@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// keyCache holds the public keys of secret scopes, keyed by the path of the scope's
+// public-key endpoint, so bulk writes to one scope fetch its key once. The zero value
+// is ready to use.
+type keyCache struct {
+	mu   sync.Mutex
+	keys map[string]*SecretEncryption
+}
+
+func (k *keyCache) get(scope string) *SecretEncryption {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keys[scope]
+}
+
+func (k *keyCache) put(scope string, encryption *SecretEncryption) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys == nil {
+		k.keys = make(map[string]*SecretEncryption)
+	}
+	k.keys[scope] = encryption
+}
+
+// invalidate drops the cached key of a scope if it is still the one with keyID, so a
+// key another goroutine already refreshed is kept
+func (k *keyCache) invalidate(scope, keyID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if cached, ok := k.keys[scope]; ok && cached.KeyID == keyID {
+		delete(k.keys, scope)
+	}
+}
+
+// publicKey returns the cached public key of a scope, fetching and caching it on first use
+func (c *Client) publicKey(scope string, fetch func() (*SecretEncryption, error)) (*SecretEncryption, error) {
+	if encryption := c.keys.get(scope); encryption != nil {
+		return encryption, nil
+	}
+
+	encryption, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c.keys.put(scope, encryption)
+	return encryption, nil
+}
+
+// withPublicKey calls write with the cached public key of a scope. When GitHub rejects
+// the key ID because the key was rotated since it was cached, the key is fetched again
+// and write is retried once.
+func (c *Client) withPublicKey(scope string, fetch func() (*SecretEncryption, error), write func(*SecretEncryption) error) error {
+	encryption, err := c.publicKey(scope, fetch)
+	if err != nil {
+		return err
+	}
+
+	err = write(encryption)
+	if !isKeyMismatch(err) {
+		return err
+	}
+
+	if Verbose {
+		log.Printf("Public key %s of %s was rejected, fetching it again", encryption.KeyID, scope)
+	}
+	c.keys.invalidate(scope, encryption.KeyID)
+	encryption, err = c.publicKey(scope, fetch)
+	if err != nil {
+		return err
+	}
+	return write(encryption)
+}
+
+// isKeyMismatch reports whether err is GitHub rejecting a secret because its key_id
+// does not match the scope's current public key, or a pre-encrypted secret found not
+// to match the cached key before it was sent
+func isKeyMismatch(err error) bool {
+	if errors.Is(err, errKeyMismatch) {
+		return true
+	}
+
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	switch errResp.Response.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+	default:
+		return false
+	}
+
+	if strings.Contains(strings.ToLower(errResp.Message), "key_id") {
+		return true
+	}
+	for _, e := range errResp.Errors {
+		if e.Field == "key_id" || strings.Contains(strings.ToLower(e.Message), "key_id") {
+			return true
+		}
+	}
+	return false
+}
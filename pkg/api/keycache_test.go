@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// fakeKeyServer serves public keys for any scope and accepts secrets encrypted with
+// the current key ID, rejecting others the way GitHub does after a key rotation
+type fakeKeyServer struct {
+	mu         sync.Mutex
+	keyID      string
+	keyFetches map[string]int
+	puts       int
+	lastValue  string
+}
+
+func (f *fakeKeyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/public-key") {
+		f.keyFetches[r.URL.Path]++
+		json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: f.keyID})
+		return
+	}
+
+	var body struct {
+		KeyID          string `json:"key_id"`
+		EncryptedValue string `json:"encrypted_value"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.KeyID != f.keyID {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "Bad request: key_id does not match the current public key"}`))
+		return
+	}
+	f.puts++
+	f.lastValue = body.EncryptedValue
+	w.WriteHeader(http.StatusCreated)
+}
+
+func setupFakeKeyServer(t *testing.T) (*fakeKeyServer, *Client) {
+	t.Helper()
+	fake := &fakeKeyServer{keyID: "key-1", keyFetches: make(map[string]int)}
+	return fake, newTestAPIClient(t, fake)
+}
+
+func TestPublicKeyCachedPerScope(t *testing.T) {
+	fake, client := setupFakeKeyServer(t)
+
+	for _, name := range []string{"A", "B", "C"} {
+		secret := &github.EncryptedSecret{Name: name, EncryptedValue: "value"}
+		if err := client.CreateOrUpdateRepoSecret("testorg", "repo", secret); err != nil {
+			t.Fatalf("CreateOrUpdateRepoSecret returned error: %v", err)
+		}
+		if err := client.CreateOrUpdateRepoDependabotSecret("testorg", "repo", secret); err != nil {
+			t.Fatalf("CreateOrUpdateRepoDependabotSecret returned error: %v", err)
+		}
+		if err := client.CreateOrUpdateEnvironmentSecret("testorg", "repo", "prod", secret); err != nil {
+			t.Fatalf("CreateOrUpdateEnvironmentSecret returned error: %v", err)
+		}
+	}
+
+	want := map[string]int{
+		"/repos/testorg/repo/actions/secrets/public-key":           1,
+		"/repos/testorg/repo/dependabot/secrets/public-key":        1,
+		"/repos/testorg/repo/environments/prod/secrets/public-key": 1,
+	}
+	for path, count := range want {
+		if fake.keyFetches[path] != count {
+			t.Errorf("%s fetched %d times, want %d", path, fake.keyFetches[path], count)
+		}
+	}
+	if fake.puts != 9 {
+		t.Errorf("puts = %d, want 9", fake.puts)
+	}
+}
+
+func TestPublicKeyRefetchedAfterRotation(t *testing.T) {
+	fake, client := setupFakeKeyServer(t)
+
+	secret := &github.EncryptedSecret{Name: "A", EncryptedValue: "value"}
+	if err := client.CreateOrUpdateOrgSecret("testorg", secret); err != nil {
+		t.Fatalf("CreateOrUpdateOrgSecret returned error: %v", err)
+	}
+
+	fake.keyID = "key-2"
+	if err := client.CreateOrUpdateOrgSecret("testorg", secret); err != nil {
+		t.Fatalf("CreateOrUpdateOrgSecret after rotation returned error: %v", err)
+	}
+	if err := client.CreateOrUpdateOrgSecret("testorg", secret); err != nil {
+		t.Fatalf("CreateOrUpdateOrgSecret returned error: %v", err)
+	}
+
+	if got := fake.keyFetches["/orgs/testorg/actions/secrets/public-key"]; got != 2 {
+		t.Errorf("public key fetched %d times, want 2", got)
+	}
+	if fake.puts != 3 {
+		t.Errorf("puts = %d, want 3", fake.puts)
+	}
+}
+
+func TestPublicKeyNotRefetchedOnOtherErrors(t *testing.T) {
+	var keyFetches int
+	client := newTestAPIClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/public-key") {
+			keyFetches++
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "key-1"})
+			return
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "Validation Failed", "errors": [{"field": "visibility", "code": "invalid"}]}`))
+	}))
+
+	secret := &github.EncryptedSecret{Name: "A", EncryptedValue: "value", Visibility: "bogus"}
+	if err := client.CreateOrUpdateOrgSecret("testorg", secret); err == nil {
+		t.Fatal("Expected error but got nil")
+	}
+	if keyFetches != 1 {
+		t.Errorf("public key fetched %d times, want 1", keyFetches)
+	}
+}
+
+func TestPreEncryptedSecretSentAsIs(t *testing.T) {
+	fake, client := setupFakeKeyServer(t)
+
+	secret := &github.EncryptedSecret{Name: "A", KeyID: "key-1", EncryptedValue: "c2VhbGVk"}
+	if err := client.CreateOrUpdateRepoSecret("testorg", "repo", secret); err != nil {
+		t.Fatalf("CreateOrUpdateRepoSecret returned error: %v", err)
+	}
+	if fake.lastValue != "c2VhbGVk" {
+		t.Errorf("encrypted_value = %q, want the pre-encrypted value unchanged", fake.lastValue)
+	}
+}
+
+func TestPreEncryptedSecretAfterRotation(t *testing.T) {
+	fake, client := setupFakeKeyServer(t)
+
+	if err := client.CreateOrUpdateRepoSecret("testorg", "repo", &github.EncryptedSecret{Name: "A", EncryptedValue: "value"}); err != nil {
+		t.Fatalf("CreateOrUpdateRepoSecret returned error: %v", err)
+	}
+
+	// The bundle was encrypted with the rotated key, so the cached key is refreshed
+	fake.keyID = "key-2"
+	secret := &github.EncryptedSecret{Name: "B", KeyID: "key-2", EncryptedValue: "c2VhbGVk"}
+	if err := client.CreateOrUpdateRepoSecret("testorg", "repo", secret); err != nil {
+		t.Fatalf("CreateOrUpdateRepoSecret returned error: %v", err)
+	}
+	if got := fake.keyFetches["/repos/testorg/repo/actions/secrets/public-key"]; got != 2 {
+		t.Errorf("public key fetched %d times, want 2", got)
+	}
+}
+
+func TestPreEncryptedSecretStaleKey(t *testing.T) {
+	fake, client := setupFakeKeyServer(t)
+
+	secret := &github.EncryptedSecret{Name: "A", KeyID: "key-0", EncryptedValue: "c2VhbGVk"}
+	err := client.CreateOrUpdateRepoSecret("testorg", "repo", secret)
+	if !errors.Is(err, errKeyMismatch) {
+		t.Fatalf("CreateOrUpdateRepoSecret error = %v, want key mismatch", err)
+	}
+	if fake.puts != 0 {
+		t.Errorf("puts = %d, want the secret never sent", fake.puts)
+	}
+	if got := fake.keyFetches["/repos/testorg/repo/actions/secrets/public-key"]; got != 2 {
+		t.Errorf("public key fetched %d times, want 2", got)
+	}
+}
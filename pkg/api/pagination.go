@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// perPage is the page size requested from list endpoints, the maximum GitHub allows
+const perPage = 100
+
+// paginate returns an iterator over every item of a list endpoint whose response wraps
+// its items in the given field, e.g. {"total_count": 2, "secrets": [...]}. Pages are
+// fetched on demand by following the Link header, falling back to total_count when
+// the header is absent. The description is used in error messages.
+func paginate[T any](c *Client, path, field, description string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		seen := 0
+		page := 1
+		for {
+			if err := c.ensureValidToken(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			req, err := c.github.NewRequest("GET", pageURL(path, page), nil)
+			if err != nil {
+				yield(zero, fmt.Errorf("failed to create request: %w", err))
+				return
+			}
+
+			var body map[string]json.RawMessage
+			resp, err := c.github.Do(c.ctx, req, &body)
+			if err != nil {
+				yield(zero, fmt.Errorf("failed to list %s: %w", description, err))
+				return
+			}
+
+			var items []T
+			if raw, ok := body[field]; ok {
+				if err := json.Unmarshal(raw, &items); err != nil {
+					yield(zero, fmt.Errorf("failed to decode %s: %w", description, err))
+					return
+				}
+			}
+			var total int
+			if raw, ok := body["total_count"]; ok {
+				json.Unmarshal(raw, &total)
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			seen += len(items)
+
+			switch {
+			case resp.NextPage != 0:
+				page = resp.NextPage
+			case resp.Header.Get("Link") == "" && len(items) > 0 && seen < total:
+				page++
+			default:
+				return
+			}
+		}
+	}
+}
+
+// collect gathers every item of an iterator, stopping at the first error
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func pageURL(path string, page int) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%sper_page=%d&page=%d", path, sep, perPage, page)
+}
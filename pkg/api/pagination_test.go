@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func TestListOrgSecretsFollowsLinkHeader(t *testing.T) {
+	var serverURL string
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/actions/secrets": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("per_page"); got != "100" {
+				t.Errorf("per_page = %q, want 100", got)
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page < 3 {
+				w.Header().Set("Link", fmt.Sprintf(`<%sorgs/testorg/actions/secrets?per_page=100&page=%d>; rel="next"`, serverURL, page+1))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&github.Secrets{
+				TotalCount: 3,
+				Secrets:    []*github.Secret{{Name: fmt.Sprintf("SECRET%d", page)}},
+			})
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+	serverURL = server.URL + "/"
+
+	secrets, err := client.ListOrgSecrets("testorg")
+	if err != nil {
+		t.Fatalf("ListOrgSecrets returned error: %v", err)
+	}
+
+	if len(secrets) != 3 {
+		t.Fatalf("ListOrgSecrets returned %d secrets, want 3", len(secrets))
+	}
+	for i, secret := range secrets {
+		if want := fmt.Sprintf("SECRET%d", i+1); secret.Name != want {
+			t.Errorf("secret %d = %q, want %q", i, secret.Name, want)
+		}
+	}
+}
+
+func TestListRepoVariablesFallsBackToTotalCount(t *testing.T) {
+	requests := 0
+	handlers := map[string]http.HandlerFunc{
+		"/repos/testorg/repo/actions/variables": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"total_count": 2,
+				"variables":   []*Variable{{Name: fmt.Sprintf("VAR%d", page), Value: "v"}},
+			})
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	variables, err := client.ListRepoVariables("testorg", "repo")
+	if err != nil {
+		t.Fatalf("ListRepoVariables returned error: %v", err)
+	}
+
+	if len(variables) != 2 || variables[1].Name != "VAR2" {
+		t.Errorf("ListRepoVariables = %+v, want VAR1 and VAR2", variables)
+	}
+	if requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+}
+
+func TestIterOrgSecretsStopsEarly(t *testing.T) {
+	var serverURL string
+	requests := 0
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/actions/secrets": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			w.Header().Set("Link", fmt.Sprintf(`<%sorgs/testorg/actions/secrets?per_page=100&page=%d>; rel="next"`, serverURL, page+1))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&github.Secrets{
+				TotalCount: 1000,
+				Secrets:    []*github.Secret{{Name: "A"}, {Name: "B"}},
+			})
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+	serverURL = server.URL + "/"
+
+	var names []string
+	for secret, err := range client.IterOrgSecrets("testorg") {
+		if err != nil {
+			t.Fatalf("IterOrgSecrets returned error: %v", err)
+		}
+		names = append(names, secret.Name)
+		if len(names) == 3 {
+			break
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+}
+
+func TestListEnvironmentSecretsError(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"/repos/testorg/repo/environments/env/secrets": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	if _, err := client.ListEnvironmentSecrets("testorg", "repo", "env"); err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
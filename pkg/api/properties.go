@@ -0,0 +1,222 @@
+package api
+
+import (
+	"fmt"
+	"iter"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// PropertyValue is the value of one custom property of a repository. The value is
+// a string, a list of strings for multi-select properties, or null when unset.
+type PropertyValue struct {
+	PropertyName string      `json:"property_name"`
+	Value        interface{} `json:"value"`
+}
+
+// Values returns the property's values: none when it is unset, one for most property
+// types and any number for multi-select properties
+func (v *PropertyValue) Values() []string {
+	switch value := v.Value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case []string:
+		return value
+	}
+	return nil
+}
+
+// PropertySchema is the definition of an organization custom property
+type PropertySchema struct {
+	PropertyName     string      `json:"property_name"`
+	ValueType        string      `json:"value_type"`
+	Required         bool        `json:"required,omitempty"`
+	DefaultValue     interface{} `json:"default_value,omitempty"`
+	Description      string      `json:"description,omitempty"`
+	AllowedValues    []string    `json:"allowed_values,omitempty"`
+	ValuesEditableBy string      `json:"values_editable_by,omitempty"`
+}
+
+// maxPropertyRepositories is the most repositories one organization property values
+// update can name
+const maxPropertyRepositories = 30
+
+// RepositoryPropertyValues is the custom property values of one repository
+type RepositoryPropertyValues struct {
+	RepositoryID       int64            `json:"repository_id"`
+	RepositoryName     string           `json:"repository_name"`
+	RepositoryFullName string           `json:"repository_full_name"`
+	Properties         []*PropertyValue `json:"properties"`
+}
+
+// PropertyMap returns the repository's property values by property name
+func (r *RepositoryPropertyValues) PropertyMap() map[string][]string {
+	properties := make(map[string][]string, len(r.Properties))
+	for _, property := range r.Properties {
+		properties[property.PropertyName] = property.Values()
+	}
+	return properties
+}
+
+// ListPropertySchemas returns the custom properties defined by an organization
+func (c *Client) ListPropertySchemas(org string) ([]*PropertySchema, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.github.NewRequest("GET", fmt.Sprintf("orgs/%s/properties/schema", org), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var schemas []*PropertySchema
+	_, err = c.github.Do(c.ctx, req, &schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom properties: %w", err)
+	}
+	return schemas, nil
+}
+
+// GetRepositoryPropertyValues returns the custom property values of a repository
+func (c *Client) GetRepositoryPropertyValues(owner, repo string) ([]*PropertyValue, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.github.NewRequest("GET", fmt.Sprintf("repos/%s/%s/properties/values", owner, repo), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var values []*PropertyValue
+	_, err = c.github.Do(c.ctx, req, &values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property values of %s/%s: %w", owner, repo, err)
+	}
+	return values, nil
+}
+
+// SetRepositoryPropertyValues creates or updates custom property values of a
+// repository. A nil value removes the property's value.
+func (c *Client) SetRepositoryPropertyValues(owner, repo string, values []*PropertyValue) error {
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	body := struct {
+		Properties []*PropertyValue `json:"properties"`
+	}{values}
+	req, err := c.github.NewRequest("PATCH", fmt.Sprintf("repos/%s/%s/properties/values", owner, repo), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	_, err = c.github.Do(c.ctx, req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set property values of %s/%s: %w", owner, repo, err)
+	}
+	return nil
+}
+
+// SetOrgPropertyValues creates or updates custom property values of repositories of
+// an organization, naming up to 30 repositories per request. A nil value removes the
+// property's value.
+func (c *Client) SetOrgPropertyValues(org string, repoNames []string, values []*PropertyValue) error {
+	for batch := range slices.Chunk(repoNames, maxPropertyRepositories) {
+		if err := c.ensureValidToken(); err != nil {
+			return err
+		}
+
+		body := struct {
+			RepositoryNames []string         `json:"repository_names"`
+			Properties      []*PropertyValue `json:"properties"`
+		}{batch, values}
+		req, err := c.github.NewRequest("PATCH", fmt.Sprintf("orgs/%s/properties/values", org), body)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		_, err = c.github.Do(c.ctx, req, nil)
+		if err != nil {
+			return fmt.Errorf("failed to set property values of %s: %w", strings.Join(batch, ", "), err)
+		}
+	}
+	return nil
+}
+
+// ListOrgPropertyValues returns the custom property values of every repository of an organization
+func (c *Client) ListOrgPropertyValues(org string) ([]*RepositoryPropertyValues, error) {
+	return collect(c.IterOrgPropertyValues(org))
+}
+
+// IterOrgPropertyValues streams the custom property values of the repositories of an
+// organization, fetching pages as they are consumed
+func (c *Client) IterOrgPropertyValues(org string) iter.Seq2[*RepositoryPropertyValues, error] {
+	return c.iterPropertyValues(org, "")
+}
+
+// iterPropertyValues streams the custom property values of the repositories of an
+// organization that match a repository search query, or of all of them when the
+// query is empty
+func (c *Client) iterPropertyValues(org, query string) iter.Seq2[*RepositoryPropertyValues, error] {
+	path := fmt.Sprintf("orgs/%s/properties/values", org)
+	if query != "" {
+		path += "?" + url.Values{"repository_query": {query}}.Encode()
+	}
+
+	return func(yield func(*RepositoryPropertyValues, error) bool) {
+		page := 1
+		for {
+			if err := c.ensureValidToken(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			req, err := c.github.NewRequest("GET", pageURL(path, page), nil)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to create request: %w", err))
+				return
+			}
+
+			var values []*RepositoryPropertyValues
+			resp, err := c.github.Do(c.ctx, req, &values)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list repository property values: %w", err))
+				return
+			}
+			for _, value := range values {
+				if !yield(value, nil) {
+					return
+				}
+			}
+
+			if resp.NextPage == 0 {
+				return
+			}
+			page = resp.NextPage
+		}
+	}
+}
+
+// propertyQuerySyntax holds the characters that make a search term mean more than
+// the literal value
+const propertyQuerySyntax = " \t\":()\\"
+
+// propertyQuery returns the repository search query for a custom property value.
+// Values containing whitespace or search syntax are quoted, with embedded quotes and
+// backslashes escaped.
+func propertyQuery(name, value string) string {
+	if value == "" || strings.ContainsAny(value, propertyQuerySyntax) {
+		value = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	return fmt.Sprintf("props.%s:%s", name, value)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestListOrgPropertyValues(t *testing.T) {
+	var serverURL string
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			w.Header().Set("Content-Type", "application/json")
+			if page < 2 {
+				w.Header().Set("Link", fmt.Sprintf(`<%sorgs/testorg/properties/values?per_page=100&page=2>; rel="next"`, serverURL))
+				fmt.Fprint(w, `[{"repository_name": "api", "properties": [{"property_name": "team", "value": "backend"}, {"property_name": "regions", "value": ["eu", "us"]}]}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"repository_name": "web", "properties": [{"property_name": "team", "value": null}]}]`)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+	serverURL = server.URL + "/"
+
+	values, err := client.ListOrgPropertyValues("testorg")
+	if err != nil {
+		t.Fatalf("ListOrgPropertyValues returned error: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("ListOrgPropertyValues returned %d repositories, want 2", len(values))
+	}
+
+	want := map[string][]string{"team": {"backend"}, "regions": {"eu", "us"}}
+	if got := values[0].PropertyMap(); values[0].RepositoryName != "api" || !reflect.DeepEqual(got, want) {
+		t.Errorf("first repository = %s %v, want api %v", values[0].RepositoryName, got, want)
+	}
+	if got := values[1].PropertyMap(); values[1].RepositoryName != "web" || len(got["team"]) != 0 {
+		t.Errorf("second repository = %s %v, want web with team unset", values[1].RepositoryName, got)
+	}
+}
+
+func TestListPropertySchemas(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/properties/schema": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"property_name": "tier", "value_type": "single_select", "required": true, "allowed_values": ["1", "2"]}]`)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	schemas, err := client.ListPropertySchemas("testorg")
+	if err != nil {
+		t.Fatalf("ListPropertySchemas returned error: %v", err)
+	}
+	if len(schemas) != 1 || schemas[0].PropertyName != "tier" || !schemas[0].Required || len(schemas[0].AllowedValues) != 2 {
+		t.Errorf("ListPropertySchemas = %+v, want the tier property", schemas)
+	}
+}
+
+func TestRepositoryPropertyValues(t *testing.T) {
+	var body map[string]any
+	handlers := map[string]http.HandlerFunc{
+		"/repos/testorg/repo/properties/values": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PATCH" {
+				json.NewDecoder(r.Body).Decode(&body)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"property_name": "team", "value": "backend"}]`)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	values, err := client.GetRepositoryPropertyValues("testorg", "repo")
+	if err != nil {
+		t.Fatalf("GetRepositoryPropertyValues returned error: %v", err)
+	}
+	if len(values) != 1 || values[0].PropertyName != "team" || !reflect.DeepEqual(values[0].Values(), []string{"backend"}) {
+		t.Errorf("GetRepositoryPropertyValues = %+v, want team=backend", values)
+	}
+
+	err = client.SetRepositoryPropertyValues("testorg", "repo", []*PropertyValue{
+		{PropertyName: "team", Value: "frontend"},
+		{PropertyName: "tier", Value: nil},
+	})
+	if err != nil {
+		t.Fatalf("SetRepositoryPropertyValues returned error: %v", err)
+	}
+	want := map[string]any{"properties": []any{
+		map[string]any{"property_name": "team", "value": "frontend"},
+		map[string]any{"property_name": "tier", "value": nil},
+	}}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("request body = %v, want %v", body, want)
+	}
+}
+
+func TestSetOrgPropertyValuesBatches(t *testing.T) {
+	var batches [][]string
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/properties/values": func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				RepositoryNames []string         `json:"repository_names"`
+				Properties      []*PropertyValue `json:"properties"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if r.Method != "PATCH" || len(body.Properties) != 1 || body.Properties[0].PropertyName != "team" {
+				t.Errorf("request = %s %+v, want PATCH setting team", r.Method, body)
+			}
+			batches = append(batches, body.RepositoryNames)
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	var names []string
+	for i := range 65 {
+		names = append(names, fmt.Sprintf("repo%d", i))
+	}
+	if err := client.SetOrgPropertyValues("testorg", names, []*PropertyValue{{PropertyName: "team", Value: "backend"}}); err != nil {
+		t.Fatalf("SetOrgPropertyValues returned error: %v", err)
+	}
+	if len(batches) != 3 || len(batches[0]) != 30 || len(batches[1]) != 30 || len(batches[2]) != 5 {
+		t.Errorf("batches = %v, want 30, 30 and 5 repositories", batches)
+	}
+}
+
+func TestPropertyQuery(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"backend", `props.team:backend`},
+		{"R&D ops", `props.team:"R&D ops"`},
+		{"a:b", `props.team:"a:b"`},
+		{`say "hi"`, `props.team:"say \"hi\""`},
+		{`back\slash`, `props.team:"back\\slash"`},
+		{"(x)", `props.team:"(x)"`},
+		{"", `props.team:""`},
+	}
+	for _, tc := range tests {
+		if got := propertyQuery("team", tc.value); got != tc.want {
+			t.Errorf("propertyQuery(team, %q) = %s, want %s", tc.value, got, tc.want)
+		}
+	}
+}
@@ -2,11 +2,16 @@ package api
 
 import (
 	"fmt"
+	"iter"
+	"slices"
+	"strings"
 
 	"github.com/google/go-github/v45/github"
 )
 
-// ListRepositoriesByProperty returns all repositories in an organization that have a specific custom property value
+// ListRepositoriesByProperty returns all repositories in an organization that have a specific custom property value.
+// It finds the matching names through the custom property values listing and takes the repositories from a single
+// listing of the organization's repositories, rather than fetching each one.
 func (c *Client) ListRepositoriesByProperty(org, propertyName, propertyValue string) ([]*github.Repository, error) {
 	if err := c.ensureValidToken(); err != nil {
 		return nil, err
@@ -23,44 +28,82 @@ func (c *Client) ListRepositoriesByProperty(org, propertyName, propertyValue str
 		return nil, fmt.Errorf("property value cannot be empty")
 	}
 
-	var matchingRepos []*github.Repository
-	page := 1
-
-	// Use the custom properties API to get repositories with the specific property value
-	for {
-		url := fmt.Sprintf("orgs/%s/properties/values?property_name=%s&value=%s&page=%d&per_page=100",
-			org, propertyName, propertyValue, page)
-
-		req, err := c.github.NewRequest("GET", url, nil)
+	// The query narrows the listing on the server; the values are still checked here
+	// because search matching is looser than equality
+	var names []string
+	for values, err := range c.iterPropertyValues(org, propertyQuery(propertyName, propertyValue)) {
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+			return nil, fmt.Errorf("failed to get repositories by property: %w", err)
 		}
-
-		var response struct {
-			Repositories []struct {
-				Name string `json:"name"`
-			} `json:"repositories"`
-			HasNextPage bool `json:"has_next_page"`
+		if slices.Contains(values.PropertyMap()[propertyName], propertyValue) {
+			names = append(names, values.RepositoryName)
 		}
-		_, err = c.github.Do(c.ctx, req, &response)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	repos := make(map[string]*github.Repository)
+	for repo, err := range c.IterOrgRepositories(org) {
 		if err != nil {
-			return nil, fmt.Errorf("failed to get repositories by property: %w", err)
+			return nil, err
 		}
+		repos[strings.ToLower(repo.GetName())] = repo
+	}
 
-		// Get full repository objects for each matching repository
-		for _, repoInfo := range response.Repositories {
-			repo, _, err := c.github.Repositories.Get(c.ctx, org, repoInfo.Name)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get repository %s: %w", repoInfo.Name, err)
-			}
+	// A repository deleted between the two listings is left out
+	var matchingRepos []*github.Repository
+	for _, name := range names {
+		if repo, ok := repos[strings.ToLower(name)]; ok {
 			matchingRepos = append(matchingRepos, repo)
 		}
+	}
+	return matchingRepos, nil
+}
 
-		if !response.HasNextPage {
-			break
-		}
-		page++
+// GetRepository returns a single repository
+func (c *Client) GetRepository(owner, repo string) (*github.Repository, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
 	}
 
-	return matchingRepos, nil
+	repository, _, err := c.github.Repositories.Get(c.ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository %s/%s: %w", owner, repo, err)
+	}
+	return repository, nil
+}
+
+// ListOrgRepositories returns every repository of an organization
+func (c *Client) ListOrgRepositories(org string) ([]*github.Repository, error) {
+	return collect(c.IterOrgRepositories(org))
+}
+
+// IterOrgRepositories streams the repositories of an organization, fetching pages as they are consumed
+func (c *Client) IterOrgRepositories(org string) iter.Seq2[*github.Repository, error] {
+	return func(yield func(*github.Repository, error) bool) {
+		opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: perPage}}
+		for {
+			if err := c.ensureValidToken(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			repos, resp, err := c.github.Repositories.ListByOrg(c.ctx, org, opts)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list organization repositories: %w", err))
+				return
+			}
+			for _, repo := range repos {
+				if !yield(repo, nil) {
+					return
+				}
+			}
+
+			if resp.NextPage == 0 {
+				return
+			}
+			opts.Page = resp.NextPage
+		}
+	}
 }
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func TestListOrgRepositories(t *testing.T) {
+	var serverURL string
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/repos": func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 0 {
+				page = 1
+			}
+			if page < 2 {
+				w.Header().Set("Link", fmt.Sprintf(`<%sorgs/testorg/repos?per_page=100&page=%d>; rel="next"`, serverURL, page+1))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]*github.Repository{{Name: github.String(fmt.Sprintf("repo%d", page))}})
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+	serverURL = server.URL + "/"
+
+	repos, err := client.ListOrgRepositories("testorg")
+	if err != nil {
+		t.Fatalf("ListOrgRepositories returned error: %v", err)
+	}
+	if len(repos) != 2 || repos[0].GetName() != "repo1" || repos[1].GetName() != "repo2" {
+		t.Errorf("ListOrgRepositories = %v, want repo1 and repo2", repos)
+	}
+}
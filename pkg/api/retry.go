@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how requests that hit rate limits or server errors are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried; zero disables retries
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry, doubled on each further attempt
+	BaseDelay time.Duration
+	// MaxDelay caps a single wait. Rate limit resets further away than this are not
+	// waited for, and the rate limit response is returned instead.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when ClientOptions does not specify a retry policy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	BaseDelay:  time.Second,
+	MaxDelay:   2 * time.Minute,
+}
+
+// maxInspectedBody bounds how much of a 403 response is read to detect secondary rate limits
+const maxInspectedBody = 64 * 1024
+
+// retryTransport retries requests that fail with a rate limit, honouring Retry-After
+// and X-RateLimit-Reset before falling back to jittered exponential backoff. Server
+// and network errors are retried with backoff for idempotent methods only, as the
+// failed request may still have taken effect.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+	sleep  func(context.Context, time.Duration) error
+}
+
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	return &retryTransport{next: next, policy: policy, sleep: sleepContext}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+
+		if attempt >= t.policy.MaxRetries || !canRewind(req) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		wait, retry := t.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if Verbose {
+			status := "error"
+			if resp != nil {
+				status = resp.Status
+			}
+			log.Printf("Request %s %s failed (%s), retrying in %s (attempt %d of %d)",
+				req.Method, req.URL.Path, status, wait.Round(time.Millisecond), attempt+1, t.policy.MaxRetries)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// canRewind reports whether the request body can be sent again
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryDelay decides whether a response should be retried and how long to wait first
+func (t *retryTransport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		return t.backoff(attempt), isIdempotent(req.Method)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden:
+		if !isRateLimited(resp) {
+			return 0, false
+		}
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		return t.backoff(attempt), isIdempotent(req.Method)
+	default:
+		return 0, false
+	}
+
+	if wait, ok := serverDelay(resp); ok {
+		if wait > t.policy.MaxDelay {
+			return 0, false
+		}
+		return wait, true
+	}
+	return t.backoff(attempt), true
+}
+
+// isIdempotent reports whether sending a request with the method twice has the same
+// effect as sending it once. Rate limited requests were rejected before taking effect
+// and are retried whatever the method.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// isRateLimited reports whether a 403 response is a primary or secondary rate limit
+// rather than a permissions error. The body is restored after inspection.
+func isRateLimited(resp *http.Response) bool {
+	if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInspectedBody))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(body)), "rate limit")
+}
+
+// serverDelay returns the wait requested by Retry-After or, once the primary rate
+// limit is exhausted, the time until X-RateLimit-Reset
+func serverDelay(resp *http.Response) (time.Duration, bool) {
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(time.Until(at), 0), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// Allow a second of clock skew so the retry lands after the reset
+			return max(time.Until(time.Unix(reset, 0)), 0) + time.Second, true
+		}
+	}
+
+	return 0, false
+}
+
+// backoff returns an exponential delay for the attempt with full jitter in its upper half
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.policy.BaseDelay << attempt
+	if delay <= 0 || delay > t.policy.MaxDelay {
+		delay = t.policy.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
+
+// sleepContext waits for the duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRecordingRetryTransport returns a retry transport whose waits are recorded instead of slept
+func newRecordingRetryTransport(policy RetryPolicy) (*retryTransport, *[]time.Duration) {
+	var waits []time.Duration
+	return &retryTransport{
+		next:   http.DefaultTransport,
+		policy: policy,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		},
+	}, &waits
+}
+
+var testRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		respond   func(w http.ResponseWriter, attempt int) bool
+		wantCalls int
+		wantCode  int
+		checkWait func(t *testing.T, waits []time.Duration)
+	}{
+		{
+			name: "retry after header",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				if attempt == 1 {
+					w.Header().Set("Retry-After", "7")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return true
+				}
+				return false
+			},
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+			checkWait: func(t *testing.T, waits []time.Duration) {
+				if len(waits) != 1 || waits[0] != 7*time.Second {
+					t.Errorf("waits = %v, want [7s]", waits)
+				}
+			},
+		},
+		{
+			name: "primary rate limit reset",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				if attempt == 1 {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(10*time.Second).Unix(), 10))
+					w.WriteHeader(http.StatusForbidden)
+					return true
+				}
+				return false
+			},
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+			checkWait: func(t *testing.T, waits []time.Duration) {
+				if len(waits) != 1 || waits[0] < 9*time.Second || waits[0] > 12*time.Second {
+					t.Errorf("waits = %v, want about 11s", waits)
+				}
+			},
+		},
+		{
+			name: "secondary rate limit body",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				if attempt == 1 {
+					w.WriteHeader(http.StatusForbidden)
+					io.WriteString(w, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+					return true
+				}
+				return false
+			},
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+			checkWait: func(t *testing.T, waits []time.Duration) {
+				if len(waits) != 1 || waits[0] < 500*time.Millisecond || waits[0] > time.Second {
+					t.Errorf("waits = %v, want backoff between 500ms and 1s", waits)
+				}
+			},
+		},
+		{
+			name: "server errors back off exponentially",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				if attempt <= 2 {
+					w.WriteHeader(http.StatusBadGateway)
+					return true
+				}
+				return false
+			},
+			wantCalls: 3,
+			wantCode:  http.StatusOK,
+			checkWait: func(t *testing.T, waits []time.Duration) {
+				if len(waits) != 2 || waits[1] < time.Second || waits[1] > 2*time.Second {
+					t.Errorf("waits = %v, want second backoff between 1s and 2s", waits)
+				}
+			},
+		},
+		{
+			name: "permission error is not retried",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, `{"message":"Resource not accessible by integration"}`)
+				return true
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name: "retry budget exhausted",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return true
+			},
+			wantCalls: 4,
+			wantCode:  http.StatusServiceUnavailable,
+		},
+		{
+			name: "reset beyond max delay",
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return true
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusTooManyRequests,
+		},
+		{
+			name:   "server error on post is not retried",
+			method: http.MethodPost,
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				w.WriteHeader(http.StatusBadGateway)
+				return true
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusBadGateway,
+		},
+		{
+			name:   "rate limit on post is retried",
+			method: http.MethodPost,
+			respond: func(w http.ResponseWriter, attempt int) bool {
+				if attempt == 1 {
+					w.Header().Set("Retry-After", "2")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return true
+				}
+				return false
+			},
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if !tc.respond(w, calls) {
+					io.WriteString(w, "ok")
+				}
+			}))
+			defer server.Close()
+
+			transport, waits := newRecordingRetryTransport(testRetryPolicy)
+			client := &http.Client{Transport: transport}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, server.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request returned error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.wantCode {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("made %d calls, want %d", calls, tc.wantCalls)
+			}
+			if tc.checkWait != nil {
+				tc.checkWait(t, *waits)
+			}
+		})
+	}
+}
+
+func TestRetryTransportResendsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	transport, _ := newRecordingRetryTransport(testRetryPolicy)
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"encrypted_value":"abc"}`))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("bodies = %q, want the same body sent twice", bodies)
+	}
+}
+
+func TestRetryTransportRateLimitBodyPreserved(t *testing.T) {
+	const message = `{"message":"Must have admin rights to Repository."}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, message)
+	}))
+	defer server.Close()
+
+	transport, _ := newRecordingRetryTransport(testRetryPolicy)
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request returned error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != message {
+		t.Errorf("body = %q, want %q", body, message)
+	}
+}
+
+func TestClientOptionsRetryPolicy(t *testing.T) {
+	var opts *ClientOptions
+	if got := opts.retryPolicy(); got != DefaultRetryPolicy {
+		t.Errorf("nil options policy = %+v, want default", got)
+	}
+
+	custom := RetryPolicy{MaxRetries: 1}
+	opts = &ClientOptions{Retry: &custom}
+	if got := opts.retryPolicy(); got != custom {
+		t.Errorf("policy = %+v, want %+v", got, custom)
+	}
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"iter"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// SecretType is the GitHub feature a secret is stored for
+type SecretType string
+
+const (
+	SecretTypeActions    SecretType = "actions"
+	SecretTypeDependabot SecretType = "dependabot"
+	SecretTypeCodespaces SecretType = "codespaces"
+)
+
+// SecretLevel is where a secret is stored
+type SecretLevel string
+
+const (
+	SecretLevelOrg         SecretLevel = "organization"
+	SecretLevelRepo        SecretLevel = "repository"
+	SecretLevelEnvironment SecretLevel = "environment"
+	SecretLevelUser        SecretLevel = "user"
+)
+
+// SecretScope identifies a collection of secrets: the secrets of one type at an
+// organization, a repository, a repository environment or the authenticated user.
+// Environments only hold Actions secrets and users only hold Codespaces secrets.
+type SecretScope struct {
+	Type        SecretType
+	Level       SecretLevel
+	Owner       string
+	Repo        string
+	Environment string
+}
+
+// OrgSecretScope returns the scope of an organization's secrets of the given type
+func OrgSecretScope(secretType SecretType, org string) SecretScope {
+	return SecretScope{Type: secretType, Level: SecretLevelOrg, Owner: org}
+}
+
+// RepoSecretScope returns the scope of a repository's secrets of the given type
+func RepoSecretScope(secretType SecretType, owner, repo string) SecretScope {
+	return SecretScope{Type: secretType, Level: SecretLevelRepo, Owner: owner, Repo: repo}
+}
+
+// EnvironmentSecretScope returns the scope of a repository environment's Actions secrets
+func EnvironmentSecretScope(owner, repo, environment string) SecretScope {
+	return SecretScope{Type: SecretTypeActions, Level: SecretLevelEnvironment, Owner: owner, Repo: repo, Environment: environment}
+}
+
+// UserSecretScope returns the scope of the authenticated user's Codespaces secrets
+func UserSecretScope() SecretScope {
+	return SecretScope{Type: SecretTypeCodespaces, Level: SecretLevelUser}
+}
+
+// path returns the API path of the scope's secrets collection
+func (s SecretScope) path() (string, error) {
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	switch s.Level {
+	case SecretLevelOrg:
+		return fmt.Sprintf("orgs/%s/%s/secrets", s.Owner, s.Type), nil
+	case SecretLevelRepo:
+		return fmt.Sprintf("repos/%s/%s/%s/secrets", s.Owner, s.Repo, s.Type), nil
+	case SecretLevelEnvironment:
+		return environmentURL(s.Owner, s.Repo, s.Environment) + "/secrets", nil
+	default:
+		return fmt.Sprintf("user/%s/secrets", s.Type), nil
+	}
+}
+
+func (s SecretScope) validate() error {
+	switch s.Type {
+	case SecretTypeActions, SecretTypeDependabot, SecretTypeCodespaces:
+	default:
+		return fmt.Errorf("unsupported secret type: %q", s.Type)
+	}
+
+	switch s.Level {
+	case SecretLevelOrg:
+		if s.Owner == "" {
+			return fmt.Errorf("organization secret scope requires an organization")
+		}
+	case SecretLevelRepo:
+		if s.Owner == "" || s.Repo == "" {
+			return fmt.Errorf("repository secret scope requires an owner and repository")
+		}
+	case SecretLevelEnvironment:
+		if s.Type != SecretTypeActions {
+			return fmt.Errorf("environments only hold Actions secrets")
+		}
+		if s.Owner == "" || s.Repo == "" || s.Environment == "" {
+			return fmt.Errorf("environment secret scope requires an owner, repository and environment")
+		}
+	case SecretLevelUser:
+		if s.Type != SecretTypeCodespaces {
+			return fmt.Errorf("users only hold Codespaces secrets")
+		}
+	default:
+		return fmt.Errorf("unsupported secret level: %q", s.Level)
+	}
+	return nil
+}
+
+// String describes the scope's secrets in messages, e.g. "repository Dependabot"
+func (s SecretScope) String() string {
+	switch s.Type {
+	case SecretTypeDependabot:
+		return string(s.Level) + " Dependabot"
+	case SecretTypeCodespaces:
+		return string(s.Level) + " Codespaces"
+	}
+	return string(s.Level)
+}
+
+// secretRequest is the body of a create or update secret request. Visibility is only
+// sent for organization secrets and selected repositories for organization and user
+// secrets, and both only when set so updates keep the existing access.
+type secretRequest struct {
+	EncryptedValue        string  `json:"encrypted_value"`
+	KeyID                 string  `json:"key_id"`
+	Visibility            string  `json:"visibility,omitempty"`
+	SelectedRepositoryIDs []int64 `json:"selected_repository_ids,omitempty"`
+}
+
+// GetPublicKey fetches the public key for encrypting the scope's secrets
+func (c *Client) GetPublicKey(scope SecretScope) (*SecretEncryption, error) {
+	path, err := scope.path()
+	if err != nil {
+		return nil, err
+	}
+	return c.getPublicKey(path+"/public-key", scope.String())
+}
+
+// ListSecrets lists the secrets of a scope
+func (c *Client) ListSecrets(scope SecretScope) ([]*github.Secret, error) {
+	return collect(c.IterSecrets(scope))
+}
+
+// IterSecrets streams the secrets of a scope, fetching pages as they are consumed
+func (c *Client) IterSecrets(scope SecretScope) iter.Seq2[*github.Secret, error] {
+	path, err := scope.path()
+	if err != nil {
+		return func(yield func(*github.Secret, error) bool) { yield(nil, err) }
+	}
+	return paginate[*github.Secret](c, path, "secrets", scope.String()+" secrets")
+}
+
+// GetSecret fetches a secret of a scope. Only its metadata is returned; GitHub never
+// returns secret values.
+func (c *Client) GetSecret(scope SecretScope, name string) (*github.Secret, error) {
+	path, err := scope.path()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.github.NewRequest("GET", path+"/"+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	secret := &github.Secret{}
+	_, err = c.github.Do(c.ctx, req, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s secret %s: %w", scope, name, err)
+	}
+	return secret, nil
+}
+
+// CreateOrUpdateSecret encrypts a secret's plaintext value with the scope's public key,
+// unless it was encrypted offline, and writes it. Visibility and selected repositories
+// are sent when set and supported by the scope.
+func (c *Client) CreateOrUpdateSecret(scope SecretScope, secret *github.EncryptedSecret) error {
+	path, err := scope.path()
+	if err != nil {
+		return err
+	}
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	fetch := func() (*SecretEncryption, error) { return c.GetPublicKey(scope) }
+	err = c.withPublicKey(path+"/public-key", fetch, func(encryption *SecretEncryption) error {
+		encryptedSecret, err := encryption.sealSecret(secret)
+		if err != nil {
+			return err
+		}
+
+		body := &secretRequest{
+			EncryptedValue: encryptedSecret.EncryptedValue,
+			KeyID:          encryptedSecret.KeyID,
+		}
+		switch scope.Level {
+		case SecretLevelOrg:
+			body.Visibility = secret.Visibility
+			body.SelectedRepositoryIDs = secret.SelectedRepositoryIDs
+		case SecretLevelUser:
+			body.SelectedRepositoryIDs = secret.SelectedRepositoryIDs
+		}
+
+		req, err := c.github.NewRequest("PUT", path+"/"+secret.Name, body)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		_, err = c.github.Do(c.ctx, req, nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create/update %s secret: %w", scope, err)
+	}
+	return nil
+}
+
+// cachedPublicKey returns the public key of a scope from the key cache, fetching it
+// on first use
+func (c *Client) cachedPublicKey(scope SecretScope) (*SecretEncryption, error) {
+	path, err := scope.path()
+	if err != nil {
+		return nil, err
+	}
+	return c.publicKey(path+"/public-key", func() (*SecretEncryption, error) { return c.GetPublicKey(scope) })
+}
+
+// DeleteSecret deletes a secret from a scope
+func (c *Client) DeleteSecret(scope SecretScope, name string) error {
+	path, err := scope.path()
+	if err != nil {
+		return err
+	}
+	if err := c.deleteSecret(path + "/" + name); err != nil {
+		return fmt.Errorf("failed to delete %s secret: %w", scope, err)
+	}
+	return nil
+}
+
+// getPublicKey fetches a public key for encrypting secrets; the description names the
+// kind of key in error messages
+func (c *Client) getPublicKey(url, description string) (*SecretEncryption, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.github.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var key struct {
+		KeyID string `json:"key_id"`
+		Key   string `json:"key"`
+	}
+	_, err = c.github.Do(c.ctx, req, &key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s public key: %w", description, err)
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	return &SecretEncryption{
+		KeyID:     key.KeyID,
+		PublicKey: publicKey,
+	}, nil
+}
+
+func (c *Client) deleteSecret(url string) error {
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	req, err := c.github.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	_, err = c.github.Do(c.ctx, req, nil)
+	return err
+}
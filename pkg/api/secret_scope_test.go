@@ -0,0 +1,265 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// fakeSecretServer serves a fixed public key for every scope and records the body of
+// each secret written
+type fakeSecretServer struct {
+	mu     sync.Mutex
+	key    string
+	bodies map[string]secretRequest
+}
+
+func (f *fakeSecretServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/public-key") {
+		json.NewEncoder(w).Encode(pk{Key: f.key, KeyID: "known-key"})
+		return
+	}
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body secretRequest
+	json.NewDecoder(r.Body).Decode(&body)
+	f.bodies[r.URL.Path] = body
+	w.WriteHeader(http.StatusCreated)
+}
+
+func setupFakeSecretServer(t *testing.T, key []byte) (*fakeSecretServer, *Client) {
+	t.Helper()
+	fake := &fakeSecretServer{key: base64.StdEncoding.EncodeToString(key), bodies: make(map[string]secretRequest)}
+	return fake, newTestAPIClient(t, fake)
+}
+
+func TestCreateOrUpdateSecretDecrypts(t *testing.T) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		set  func(c *Client, s *github.EncryptedSecret) error
+	}{
+		{"organization", "/orgs/testorg/actions/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateOrgSecret("testorg", s)
+		}},
+		{"repository", "/repos/testorg/repo/actions/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateRepoSecret("testorg", "repo", s)
+		}},
+		{"environment", "/repos/testorg/repo/environments/prod/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateEnvironmentSecret("testorg", "repo", "prod", s)
+		}},
+		{"environment (legacy)", "/repos/testorg/repo/environments/staging/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateEnvSecret("testorg", "repo", "staging", s)
+		}},
+		{"organization Dependabot", "/orgs/testorg/dependabot/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateOrgDependabotSecret("testorg", s)
+		}},
+		{"repository Dependabot", "/repos/testorg/repo/dependabot/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateRepoDependabotSecret("testorg", "repo", s)
+		}},
+		{"organization Codespaces", "/orgs/testorg/codespaces/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateOrgCodespacesSecret("testorg", s)
+		}},
+		{"repository Codespaces", "/repos/testorg/repo/codespaces/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateRepoCodespacesSecret("testorg", "repo", s)
+		}},
+		{"user Codespaces", "/user/codespaces/secrets/TOKEN", func(c *Client, s *github.EncryptedSecret) error {
+			return c.CreateOrUpdateUserCodespacesSecret(s)
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, client := setupFakeSecretServer(t, publicKey[:])
+
+			if err := tc.set(client, &github.EncryptedSecret{Name: "TOKEN", EncryptedValue: "plaintext " + tc.name}); err != nil {
+				t.Fatalf("set returned error: %v", err)
+			}
+
+			body, ok := fake.bodies[tc.path]
+			if !ok {
+				t.Fatalf("no secret written to %s", tc.path)
+			}
+			if body.KeyID != "known-key" {
+				t.Errorf("key_id = %q, want known-key", body.KeyID)
+			}
+			sealed, err := base64.StdEncoding.DecodeString(body.EncryptedValue)
+			if err != nil {
+				t.Fatalf("encrypted_value is not base64: %v", err)
+			}
+			plain, ok := box.OpenAnonymous(nil, sealed, publicKey, privateKey)
+			if !ok {
+				t.Fatal("failed to open sealed box with the known private key")
+			}
+			if string(plain) != "plaintext "+tc.name {
+				t.Errorf("decrypted value = %q, want %q", plain, "plaintext "+tc.name)
+			}
+		})
+	}
+}
+
+func TestCreateOrUpdateSecretAccess(t *testing.T) {
+	fake, client := setupFakeSecretServer(t, make([]byte, 32))
+
+	secret := &github.EncryptedSecret{Name: "TOKEN", EncryptedValue: "value", Visibility: VisibilitySelected, SelectedRepositoryIDs: github.SelectedRepoIDs{1, 2}}
+	for _, scope := range []SecretScope{
+		OrgSecretScope(SecretTypeDependabot, "testorg"),
+		RepoSecretScope(SecretTypeDependabot, "testorg", "repo"),
+		UserSecretScope(),
+	} {
+		if err := client.CreateOrUpdateSecret(scope, secret); err != nil {
+			t.Fatalf("CreateOrUpdateSecret(%s) returned error: %v", scope, err)
+		}
+	}
+
+	org := fake.bodies["/orgs/testorg/dependabot/secrets/TOKEN"]
+	if org.Visibility != VisibilitySelected || len(org.SelectedRepositoryIDs) != 2 {
+		t.Errorf("organization body = %+v, want visibility and repositories", org)
+	}
+	repo := fake.bodies["/repos/testorg/repo/dependabot/secrets/TOKEN"]
+	if repo.Visibility != "" || repo.SelectedRepositoryIDs != nil {
+		t.Errorf("repository body = %+v, want no access fields", repo)
+	}
+	user := fake.bodies["/user/codespaces/secrets/TOKEN"]
+	if user.Visibility != "" || len(user.SelectedRepositoryIDs) != 2 {
+		t.Errorf("user body = %+v, want repositories without visibility", user)
+	}
+}
+
+func TestCreateOrUpdateSecretInvalidKey(t *testing.T) {
+	fake, client := setupFakeSecretServer(t, make([]byte, 16))
+
+	// The legacy environment method used to seal with any key length
+	err := client.CreateOrUpdateEnvSecret("testorg", "repo", "prod", &github.EncryptedSecret{Name: "TOKEN", EncryptedValue: "value"})
+	if err == nil || !strings.Contains(err.Error(), "invalid public key length") {
+		t.Fatalf("CreateOrUpdateEnvSecret error = %v, want invalid public key length", err)
+	}
+	if len(fake.bodies) != 0 {
+		t.Errorf("secrets written = %v, want none", fake.bodies)
+	}
+}
+
+func TestSecretScopeValidation(t *testing.T) {
+	_, client := setupFakeSecretServer(t, make([]byte, 32))
+
+	for _, scope := range []SecretScope{
+		{Type: SecretTypeDependabot, Level: SecretLevelEnvironment, Owner: "o", Repo: "r", Environment: "e"},
+		{Type: SecretTypeActions, Level: SecretLevelUser},
+		OrgSecretScope(SecretTypeActions, ""),
+		RepoSecretScope("packages", "o", "r"),
+	} {
+		if err := client.CreateOrUpdateSecret(scope, &github.EncryptedSecret{Name: "TOKEN", EncryptedValue: "value"}); err == nil {
+			t.Errorf("CreateOrUpdateSecret(%+v) returned nil error", scope)
+		}
+	}
+}
+
+func TestSecretScopeEscapesEnvironment(t *testing.T) {
+	path, err := EnvironmentSecretScope("testorg", "repo", "prod eu").path()
+	if err != nil {
+		t.Fatalf("path returned error: %v", err)
+	}
+	if path != "repos/testorg/repo/environments/prod%20eu/secrets" {
+		t.Errorf("path = %s, want escaped environment", path)
+	}
+}
+
+func TestGetSecret(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"/repos/testorg/repo/dependabot/secrets/TOKEN": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"name": "TOKEN", "updated_at": "2026-10-01T12:00:00Z"}`)
+		},
+	}
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	secret, err := client.GetSecret(RepoSecretScope(SecretTypeDependabot, "testorg", "repo"), "TOKEN")
+	if err != nil {
+		t.Fatalf("GetSecret returned error: %v", err)
+	}
+	if secret.Name != "TOKEN" || secret.UpdatedAt.Format(time.RFC3339) != "2026-10-01T12:00:00Z" {
+		t.Errorf("GetSecret = %+v, want TOKEN updated at 2026-10-01T12:00:00Z", secret)
+	}
+
+	if _, err := client.GetSecret(RepoSecretScope(SecretTypeDependabot, "testorg", "repo"), "MISSING"); !IsNotFound(err) {
+		t.Errorf("GetSecret error = %v, want not found", err)
+	}
+}
+
+func TestEnvironmentCallsEscapeEnvironment(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	client := newTestAPIClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/public-key"):
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "env-key"})
+		case strings.HasSuffix(r.URL.Path, "/secrets"):
+			fmt.Fprint(w, `{"total_count": 0, "secrets": []}`)
+		case strings.HasSuffix(r.URL.Path, "/variables"):
+			fmt.Fprint(w, `{"total_count": 0, "variables": []}`)
+		case r.Method == "PATCH" || r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprint(w, `{"name": "A"}`)
+		}
+	}))
+
+	env := "eu/prod 1"
+	steps := []func() error{
+		func() error { _, err := client.ListEnvironmentSecrets("testorg", "repo", env); return err },
+		func() error { _, err := client.GetEnvironmentSecret("testorg", "repo", env, "A"); return err },
+		func() error { _, err := client.GetEnvPublicKey("testorg", "repo", env); return err },
+		func() error {
+			return client.CreateOrUpdateEnvironmentSecret("testorg", "repo", env, &github.EncryptedSecret{Name: "A", EncryptedValue: "v"})
+		},
+		func() error { _, err := client.ListEnvironmentVariables("testorg", "repo", env); return err },
+		func() error {
+			return client.CreateOrUpdateEnvironmentVariable("testorg", "repo", env, &Variable{Name: "A", Value: "1"})
+		},
+		func() error { return client.DeleteEnvironmentVariable("testorg", "repo", env, "A") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d returned error: %v", i, err)
+		}
+	}
+
+	const base = "/repos/testorg/repo/environments/eu%2Fprod%201"
+	want := []string{
+		"GET " + base + "/secrets",
+		"GET " + base + "/secrets/A",
+		"GET " + base + "/secrets/public-key",
+		"PUT " + base + "/secrets/A",
+		"GET " + base + "/variables",
+		"PATCH " + base + "/variables/A",
+		"DELETE " + base + "/variables/A",
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests =\n%s\nwant the public key fetched once, shared with the write, and\n%s", strings.Join(paths, "\n"), strings.Join(want, "\n"))
+	}
+}
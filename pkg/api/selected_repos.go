@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// Visibility values for organization secrets and variables
+const (
+	VisibilityAll      = "all"
+	VisibilityPrivate  = "private"
+	VisibilitySelected = "selected"
+)
+
+// ListSelectedReposForOrgSecret returns the repositories that can access an organization
+// secret whose visibility is selected
+func (c *Client) ListSelectedReposForOrgSecret(org, name string) ([]*github.Repository, error) {
+	return collect(c.IterSelectedReposForOrgSecret(org, name))
+}
+
+// IterSelectedReposForOrgSecret streams the repositories that can access an organization secret
+func (c *Client) IterSelectedReposForOrgSecret(org, name string) iter.Seq2[*github.Repository, error] {
+	return paginate[*github.Repository](c, fmt.Sprintf("orgs/%s/actions/secrets/%s/repositories", org, name), "repositories", "selected repositories")
+}
+
+// SetSelectedReposForOrgSecret replaces the repositories that can access an organization secret
+func (c *Client) SetSelectedReposForOrgSecret(org, name string, repoIDs []int64) error {
+	return c.setSelectedRepos(fmt.Sprintf("orgs/%s/actions/secrets/%s/repositories", org, name), repoIDs)
+}
+
+// AddSelectedRepoToOrgSecret gives a repository access to an organization secret
+func (c *Client) AddSelectedRepoToOrgSecret(org, name string, repoID int64) error {
+	return c.updateSelectedRepo("PUT", fmt.Sprintf("orgs/%s/actions/secrets/%s/repositories/%d", org, name, repoID))
+}
+
+// RemoveSelectedRepoFromOrgSecret removes a repository's access to an organization secret
+func (c *Client) RemoveSelectedRepoFromOrgSecret(org, name string, repoID int64) error {
+	return c.updateSelectedRepo("DELETE", fmt.Sprintf("orgs/%s/actions/secrets/%s/repositories/%d", org, name, repoID))
+}
+
+// ListSelectedReposForOrgVariable returns the repositories that can access an organization
+// variable whose visibility is selected
+func (c *Client) ListSelectedReposForOrgVariable(org, name string) ([]*github.Repository, error) {
+	return collect(c.IterSelectedReposForOrgVariable(org, name))
+}
+
+// IterSelectedReposForOrgVariable streams the repositories that can access an organization variable
+func (c *Client) IterSelectedReposForOrgVariable(org, name string) iter.Seq2[*github.Repository, error] {
+	return paginate[*github.Repository](c, fmt.Sprintf("orgs/%s/actions/variables/%s/repositories", org, name), "repositories", "selected repositories")
+}
+
+// SetSelectedReposForOrgVariable replaces the repositories that can access an organization variable
+func (c *Client) SetSelectedReposForOrgVariable(org, name string, repoIDs []int64) error {
+	return c.setSelectedRepos(fmt.Sprintf("orgs/%s/actions/variables/%s/repositories", org, name), repoIDs)
+}
+
+// AddSelectedRepoToOrgVariable gives a repository access to an organization variable
+func (c *Client) AddSelectedRepoToOrgVariable(org, name string, repoID int64) error {
+	return c.updateSelectedRepo("PUT", fmt.Sprintf("orgs/%s/actions/variables/%s/repositories/%d", org, name, repoID))
+}
+
+// RemoveSelectedRepoFromOrgVariable removes a repository's access to an organization variable
+func (c *Client) RemoveSelectedRepoFromOrgVariable(org, name string, repoID int64) error {
+	return c.updateSelectedRepo("DELETE", fmt.Sprintf("orgs/%s/actions/variables/%s/repositories/%d", org, name, repoID))
+}
+
+func (c *Client) setSelectedRepos(url string, repoIDs []int64) error {
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	if repoIDs == nil {
+		repoIDs = []int64{}
+	}
+	req := struct {
+		SelectedRepositoryIDs []int64 `json:"selected_repository_ids"`
+	}{
+		SelectedRepositoryIDs: repoIDs,
+	}
+
+	httpReq, err := c.github.NewRequest("PUT", url, req)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	_, err = c.github.Do(c.ctx, httpReq, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set selected repositories: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) updateSelectedRepo(method, url string) error {
+	if err := c.ensureValidToken(); err != nil {
+		return err
+	}
+
+	req, err := c.github.NewRequest(method, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	_, err = c.github.Do(c.ctx, req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update selected repositories: %w", err)
+	}
+
+	return nil
+}
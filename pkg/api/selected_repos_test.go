@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func TestCreateOrUpdateOrgSecretVisibility(t *testing.T) {
+	var body map[string]any
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/actions/secrets/public-key": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "keyid"})
+		},
+		"/orgs/testorg/actions/secrets/API_KEY": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "PUT" {
+				t.Errorf("method = %s, want PUT", r.Method)
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusCreated)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	err := client.CreateOrUpdateOrgSecret("testorg", &github.EncryptedSecret{
+		Name:                  "API_KEY",
+		EncryptedValue:        "value",
+		Visibility:            VisibilitySelected,
+		SelectedRepositoryIDs: github.SelectedRepoIDs{1, 2},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrUpdateOrgSecret returned error: %v", err)
+	}
+
+	if body["visibility"] != "selected" {
+		t.Errorf("visibility = %v, want selected", body["visibility"])
+	}
+	ids, _ := body["selected_repository_ids"].([]any)
+	if len(ids) != 2 || ids[0] != float64(1) || ids[1] != float64(2) {
+		t.Errorf("selected_repository_ids = %v, want [1 2]", body["selected_repository_ids"])
+	}
+}
+
+func TestCreateOrUpdateOrgSecretKeepsAccess(t *testing.T) {
+	var body map[string]any
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/dependabot/secrets/public-key": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(pk{Key: valid32ByteKey, KeyID: "keyid"})
+		},
+		"/orgs/testorg/dependabot/secrets/NPM_TOKEN": func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	if err := client.CreateOrUpdateOrgDependabotSecret("testorg", &github.EncryptedSecret{Name: "NPM_TOKEN", EncryptedValue: "value"}); err != nil {
+		t.Fatalf("CreateOrUpdateOrgDependabotSecret returned error: %v", err)
+	}
+
+	if _, ok := body["visibility"]; ok {
+		t.Errorf("visibility sent without being set: %v", body)
+	}
+	if _, ok := body["selected_repository_ids"]; ok {
+		t.Errorf("selected_repository_ids sent without being set: %v", body)
+	}
+}
+
+func TestSelectedReposForOrgSecret(t *testing.T) {
+	var requests []string
+	var setBody map[string][]int64
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/actions/secrets/API_KEY/repositories": func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch r.Method {
+			case "GET":
+				json.NewEncoder(w).Encode(map[string]any{
+					"total_count":  2,
+					"repositories": []*github.Repository{{Name: github.String("api")}, {Name: github.String("web")}},
+				})
+			case "PUT":
+				if r.URL.Path == "/orgs/testorg/actions/secrets/API_KEY/repositories" {
+					json.NewDecoder(r.Body).Decode(&setBody)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	repos, err := client.ListSelectedReposForOrgSecret("testorg", "API_KEY")
+	if err != nil {
+		t.Fatalf("ListSelectedReposForOrgSecret returned error: %v", err)
+	}
+	if len(repos) != 2 || repos[1].GetName() != "web" {
+		t.Errorf("ListSelectedReposForOrgSecret = %v, want api and web", repos)
+	}
+
+	if err := client.SetSelectedReposForOrgSecret("testorg", "API_KEY", []int64{7, 8}); err != nil {
+		t.Fatalf("SetSelectedReposForOrgSecret returned error: %v", err)
+	}
+	if ids := setBody["selected_repository_ids"]; len(ids) != 2 || ids[0] != 7 || ids[1] != 8 {
+		t.Errorf("selected_repository_ids = %v, want [7 8]", ids)
+	}
+
+	if err := client.AddSelectedRepoToOrgSecret("testorg", "API_KEY", 9); err != nil {
+		t.Fatalf("AddSelectedRepoToOrgSecret returned error: %v", err)
+	}
+	if err := client.RemoveSelectedRepoFromOrgSecret("testorg", "API_KEY", 7); err != nil {
+		t.Fatalf("RemoveSelectedRepoFromOrgSecret returned error: %v", err)
+	}
+
+	want := []string{
+		"GET /orgs/testorg/actions/secrets/API_KEY/repositories",
+		"PUT /orgs/testorg/actions/secrets/API_KEY/repositories",
+		"PUT /orgs/testorg/actions/secrets/API_KEY/repositories/9",
+		"DELETE /orgs/testorg/actions/secrets/API_KEY/repositories/7",
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("requests = %v, want %v", requests, want)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("request %d = %s, want %s", i, requests[i], want[i])
+		}
+	}
+}
+
+func TestOrgVariableVisibility(t *testing.T) {
+	var orgBody, repoBody map[string]any
+	handlers := map[string]http.HandlerFunc{
+		"/orgs/testorg/actions/variables": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				w.Write([]byte(`{"total_count": 1, "variables": [{"name": "REGION", "value": "eu", "visibility": "selected",
+					"selected_repositories_url": "https://api.github.com/orgs/testorg/actions/variables/REGION/repositories",
+					"created_at": "2024-01-02T03:04:05Z", "updated_at": "2024-02-03T04:05:06Z"}]}`))
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&orgBody)
+		},
+		"/repos/testorg/repo/actions/variables/REGION": func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&repoBody)
+		},
+	}
+
+	server, client := setupMultiHandlerTestServer(t, handlers)
+	defer server.Close()
+
+	variables, err := client.ListOrgVariables("testorg")
+	if err != nil {
+		t.Fatalf("ListOrgVariables returned error: %v", err)
+	}
+	v := variables[0]
+	if v.Visibility != VisibilitySelected || v.SelectedRepositoriesURL == "" || v.CreatedAt == nil || v.UpdatedAt.Month() != 2 {
+		t.Errorf("ListOrgVariables = %+v, want visibility, repositories URL and timestamps", v)
+	}
+
+	v.Value = "us"
+	v.SelectedRepositoryIDs = []int64{42}
+	if err := client.CreateOrUpdateOrgVariable("testorg", v); err != nil {
+		t.Fatalf("CreateOrUpdateOrgVariable returned error: %v", err)
+	}
+	if orgBody["visibility"] != "selected" || orgBody["value"] != "us" {
+		t.Errorf("organization variable body = %v, want value and visibility", orgBody)
+	}
+	if _, ok := orgBody["created_at"]; ok {
+		t.Errorf("organization variable body sent read-only fields: %v", orgBody)
+	}
+
+	if err := client.CreateOrUpdateRepoVariable("testorg", "repo", v); err != nil {
+		t.Fatalf("CreateOrUpdateRepoVariable returned error: %v", err)
+	}
+	if len(repoBody) != 2 || repoBody["name"] != "REGION" || repoBody["value"] != "us" {
+		t.Errorf("repository variable body = %v, want only name and value", repoBody)
+	}
+}
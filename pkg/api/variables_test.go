@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVariableServer stores variables by collection path and only accepts the verbs the
+// GitHub API does: POST to a collection creates, PATCH to an existing variable updates
+type fakeVariableServer struct {
+	mu        sync.Mutex
+	variables map[string]map[string]variableRequest
+	requests  []string
+}
+
+func (f *fakeVariableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	collection, name := path, ""
+	if i := strings.LastIndex(path, "/"); i >= 0 && !strings.HasSuffix(path, "/variables") {
+		collection, name = path[:i], path[i+1:]
+	}
+
+	var body variableRequest
+	switch {
+	case name == "" && r.Method == "POST":
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, exists := f.variables[collection][body.Name]; exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if strings.HasPrefix(collection, "orgs/") && body.Visibility == "" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if f.variables[collection] == nil {
+			f.variables[collection] = make(map[string]variableRequest)
+		}
+		f.variables[collection][body.Name] = body
+		w.WriteHeader(http.StatusCreated)
+	case name != "" && r.Method == "PATCH":
+		if _, exists := f.variables[collection][name]; !exists {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.variables[collection][name] = body
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func setupFakeVariableServer(t *testing.T) (*fakeVariableServer, *Client) {
+	t.Helper()
+	fake := &fakeVariableServer{variables: make(map[string]map[string]variableRequest)}
+	return fake, newTestAPIClient(t, fake)
+}
+
+func TestCreateOrUpdateVariableUpsert(t *testing.T) {
+	tests := []struct {
+		name       string
+		collection string
+		set        func(c *Client, v *Variable) error
+	}{
+		{
+			name:       "organization",
+			collection: "orgs/testorg/actions/variables",
+			set:        func(c *Client, v *Variable) error { return c.CreateOrUpdateOrgVariable("testorg", v) },
+		},
+		{
+			name:       "repository",
+			collection: "repos/testorg/repo/actions/variables",
+			set:        func(c *Client, v *Variable) error { return c.CreateOrUpdateRepoVariable("testorg", "repo", v) },
+		},
+		{
+			name:       "environment",
+			collection: "repos/testorg/repo/environments/prod/variables",
+			set: func(c *Client, v *Variable) error {
+				return c.CreateOrUpdateEnvironmentVariable("testorg", "repo", "prod", v)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, client := setupFakeVariableServer(t)
+
+			if err := tc.set(client, &Variable{Name: "LOG_LEVEL", Value: "info"}); err != nil {
+				t.Fatalf("create returned error: %v", err)
+			}
+			if err := tc.set(client, &Variable{Name: "LOG_LEVEL", Value: "debug"}); err != nil {
+				t.Fatalf("update returned error: %v", err)
+			}
+
+			if got := fake.variables[tc.collection]["LOG_LEVEL"].Value; got != "debug" {
+				t.Errorf("stored value = %q, want debug", got)
+			}
+
+			want := []string{
+				"PATCH /" + tc.collection + "/LOG_LEVEL",
+				"POST /" + tc.collection,
+				"PATCH /" + tc.collection + "/LOG_LEVEL",
+			}
+			if strings.Join(fake.requests, "\n") != strings.Join(want, "\n") {
+				t.Errorf("requests =\n%s\nwant\n%s", strings.Join(fake.requests, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCreateOrgVariableKeepsVisibility(t *testing.T) {
+	fake, client := setupFakeVariableServer(t)
+
+	if err := client.CreateOrUpdateOrgVariable("testorg", &Variable{Name: "A", Value: "1"}); err != nil {
+		t.Fatalf("CreateOrUpdateOrgVariable returned error: %v", err)
+	}
+	if err := client.CreateOrUpdateOrgVariable("testorg", &Variable{Name: "B", Value: "2", Visibility: VisibilityPrivate}); err != nil {
+		t.Fatalf("CreateOrUpdateOrgVariable returned error: %v", err)
+	}
+
+	stored := fake.variables["orgs/testorg/actions/variables"]
+	if stored["A"].Visibility != VisibilityAll || stored["B"].Visibility != VisibilityPrivate {
+		t.Errorf("visibilities = %q, %q, want all, private", stored["A"].Visibility, stored["B"].Visibility)
+	}
+}
+
+func TestCreateOrUpdateVariableError(t *testing.T) {
+	client := newTestAPIClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	if err := client.CreateOrUpdateRepoVariable("testorg", "repo", &Variable{Name: "A", Value: "1"}); err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
@@ -13,6 +13,8 @@ type Config struct {
 	InstallationID int64  `json:"installation-id"`
 	Organization   string `json:"organization,omitempty"`
 	Team           string `json:"team,omitempty"`
+	// Host is the GitHub Enterprise Server or GHE.com hostname, or an API base URL
+	Host string `json:"host,omitempty"`
 }
 
 // IsGitHubAppConfigured returns true if all required GitHub App settings are configured
@@ -20,6 +22,17 @@ func (c *Config) IsGitHubAppConfigured() bool {
 	return c.AuthServer != "" && c.AppID != 0 && c.InstallationID != 0
 }
 
+// GitHubHost returns GH_HOST when it is set, as gh itself does, or the configured host
+func (c *Config) GitHubHost() string {
+	if host := os.Getenv("GH_HOST"); host != "" {
+		return host
+	}
+	if c == nil {
+		return ""
+	}
+	return c.Host
+}
+
 func getConfigDir() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
@@ -0,0 +1,168 @@
+// Package encrypt seals secret values with a GitHub public key so they can be uploaded
+// later by a separate step. Sealing needs no network access or credentials.
+package encrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	fileio "gh-secrets-manager/pkg/io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// KeySize is the length in bytes of a GitHub secrets public key
+const KeySize = 32
+
+// PublicKey is a GitHub secrets public key in the form the public-key endpoints return it,
+// with the key base64 encoded
+type PublicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"`
+}
+
+// NewPublicKey returns the public key with the given ID and base64 encoded key
+func NewPublicKey(keyID, key string) (*PublicKey, error) {
+	publicKey := &PublicKey{KeyID: keyID, Key: key}
+	if err := publicKey.validate(); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+// ReadPublicKey reads a public key saved from a GitHub public-key endpoint, such as the
+// output of `gh api repos/OWNER/REPO/actions/secrets/public-key`
+func ReadPublicKey(path string) (*PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	publicKey := &PublicKey{}
+	if err := json.Unmarshal(data, publicKey); err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if err := publicKey.validate(); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+func (k *PublicKey) validate() error {
+	if k.KeyID == "" {
+		return fmt.Errorf("public key ID is required")
+	}
+	_, err := k.decode()
+	return err
+}
+
+func (k *PublicKey) decode() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid public key length: expected %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// Seal encrypts a value with the key and returns the base64 encoded sealed box GitHub
+// expects as a secret's encrypted_value
+func (k *PublicKey) Seal(value string) (string, error) {
+	key, err := k.decode()
+	if err != nil {
+		return "", err
+	}
+	return Seal(key, value)
+}
+
+// Seal encrypts a value with a raw public key using libsodium's sealed box and returns
+// it base64 encoded
+func Seal(publicKey []byte, value string) (string, error) {
+	if len(publicKey) != KeySize {
+		return "", fmt.Errorf("invalid public key length: expected %d bytes, got %d", KeySize, len(publicKey))
+	}
+
+	var key [KeySize]byte
+	copy(key[:], publicKey)
+
+	encrypted, err := box.SealAnonymous(nil, []byte(value), &key, rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// Payload is the body GitHub expects when creating or updating a secret
+type Payload struct {
+	EncryptedValue string `json:"encrypted_value"`
+	KeyID          string `json:"key_id"`
+}
+
+// SealPayload encrypts a value into the body of a create or update secret request
+func (k *PublicKey) SealPayload(value string) (*Payload, error) {
+	encrypted, err := k.Seal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{EncryptedValue: encrypted, KeyID: k.KeyID}, nil
+}
+
+// SealedSecret is a named secret value sealed with a bundle's key
+type SealedSecret struct {
+	Name           string `json:"name"`
+	EncryptedValue string `json:"encrypted_value"`
+}
+
+// Bundle is a set of secrets sealed offline with one public key, to be uploaded by a
+// step that holds API credentials but never sees the plaintext values
+type Bundle struct {
+	KeyID   string         `json:"key_id"`
+	Secrets []SealedSecret `json:"secrets"`
+}
+
+// SealBundle encrypts each entry with the key
+func (k *PublicKey) SealBundle(entries []fileio.SecretData) (*Bundle, error) {
+	bundle := &Bundle{KeyID: k.KeyID, Secrets: make([]SealedSecret, 0, len(entries))}
+	for _, entry := range entries {
+		encrypted, err := k.Seal(entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", entry.Name, err)
+		}
+		bundle.Secrets = append(bundle.Secrets, SealedSecret{Name: entry.Name, EncryptedValue: encrypted})
+	}
+	return bundle, nil
+}
+
+// ReadBundle reads a bundle written as JSON by the encrypt command
+func ReadBundle(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	bundle := &Bundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if bundle.KeyID == "" {
+		return nil, fmt.Errorf("bundle has no key_id")
+	}
+	if len(bundle.Secrets) == 0 {
+		return nil, fmt.Errorf("bundle contains no secrets")
+	}
+	return bundle, nil
+}
+
+// Entries returns the bundle's secrets as entries whose values are sealed with KeyID
+func (b *Bundle) Entries() []fileio.SecretData {
+	entries := make([]fileio.SecretData, 0, len(b.Secrets))
+	for _, secret := range b.Secrets {
+		entries = append(entries, fileio.SecretData{Name: secret.Name, Value: secret.EncryptedValue, KeyID: b.KeyID})
+	}
+	return entries
+}
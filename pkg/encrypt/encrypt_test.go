@@ -0,0 +1,137 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fileio "gh-secrets-manager/pkg/io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+func newKeyPair(t *testing.T) (*PublicKey, *[KeySize]byte, *[KeySize]byte) {
+	t.Helper()
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	key, err := NewPublicKey("key-1", base64.StdEncoding.EncodeToString(public[:]))
+	if err != nil {
+		t.Fatalf("NewPublicKey returned error: %v", err)
+	}
+	return key, public, private
+}
+
+func open(t *testing.T, encrypted string, public, private *[KeySize]byte) string {
+	t.Helper()
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("encrypted value is not base64: %v", err)
+	}
+	plain, ok := box.OpenAnonymous(nil, sealed, public, private)
+	if !ok {
+		t.Fatalf("failed to open sealed box")
+	}
+	return string(plain)
+}
+
+func TestSealPayload(t *testing.T) {
+	key, public, private := newKeyPair(t)
+
+	payload, err := key.SealPayload("s3cret")
+	if err != nil {
+		t.Fatalf("SealPayload returned error: %v", err)
+	}
+	if payload.KeyID != "key-1" {
+		t.Errorf("KeyID = %q, want key-1", payload.KeyID)
+	}
+	if got := open(t, payload.EncryptedValue, public, private); got != "s3cret" {
+		t.Errorf("decrypted value = %q, want s3cret", got)
+	}
+}
+
+func TestSealInvalidKey(t *testing.T) {
+	if _, err := Seal(make([]byte, 16), "value"); err == nil {
+		t.Error("Expected error for 16 byte key but got nil")
+	}
+	if _, err := NewPublicKey("key-1", "not base64!"); err == nil {
+		t.Error("Expected error for invalid base64 but got nil")
+	}
+	if _, err := NewPublicKey("", base64.StdEncoding.EncodeToString(make([]byte, KeySize))); err == nil {
+		t.Error("Expected error for missing key ID but got nil")
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	key, public, private := newKeyPair(t)
+
+	bundle, err := key.SealBundle([]fileio.SecretData{{Name: "A", Value: "one"}, {Name: "B", Value: "two"}})
+	if err != nil {
+		t.Fatalf("SealBundle returned error: %v", err)
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadBundle(path)
+	if err != nil {
+		t.Fatalf("ReadBundle returned error: %v", err)
+	}
+	entries := read.Entries()
+	want := map[string]string{"A": "one", "B": "two"}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, entry := range entries {
+		if entry.KeyID != "key-1" {
+			t.Errorf("%s KeyID = %q, want key-1", entry.Name, entry.KeyID)
+		}
+		if got := open(t, entry.Value, public, private); got != want[entry.Name] {
+			t.Errorf("%s decrypted = %q, want %q", entry.Name, got, want[entry.Name])
+		}
+	}
+}
+
+func TestReadBundleErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"invalid":   "{",
+		"no key id": `{"secrets": [{"name": "A", "encrypted_value": "x"}]}`,
+		"empty":     `{"key_id": "key-1", "secrets": []}`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadBundle(path); err == nil {
+			t.Errorf("ReadBundle(%s) returned nil error", name)
+		}
+	}
+}
+
+func TestReadPublicKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.json")
+	content := `{"key_id": "568250167242549743", "key": "` + base64.StdEncoding.EncodeToString(make([]byte, KeySize)) + `"}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ReadPublicKey(path)
+	if err != nil {
+		t.Fatalf("ReadPublicKey returned error: %v", err)
+	}
+	if key.KeyID != "568250167242549743" {
+		t.Errorf("KeyID = %q, want 568250167242549743", key.KeyID)
+	}
+}
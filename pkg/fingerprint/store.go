@@ -0,0 +1,183 @@
+// Package fingerprint records keyed fingerprints of the secret values the tool writes,
+// so drift can be detected even though GitHub never returns secret values.
+//
+// A fingerprint is an HMAC-SHA256 of the value, keyed with a secret held outside the
+// store and bound to the secret's target, kind and name, so a store can be committed
+// to a repository without revealing values or which secrets share one.
+package fingerprint
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"gh-secrets-manager/pkg/plan"
+)
+
+// KeyEnv is the environment variable holding the fingerprint key
+const KeyEnv = "GH_SECRETS_MANAGER_FINGERPRINT_KEY"
+
+// minKeyLength is the shortest key accepted, in bytes
+const minKeyLength = 16
+
+// storeVersion is the version of the store file format
+const storeVersion = 1
+
+// Entry is the fingerprint of a secret value as last written by the tool, with the
+// time GitHub reported the secret was updated
+type Entry struct {
+	Target      plan.Target `json:"target"`
+	Kind        plan.Kind   `json:"kind"`
+	Name        string      `json:"name"`
+	Fingerprint string      `json:"fingerprint"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Store is a file of fingerprints. It is safe for concurrent use.
+type Store struct {
+	path string
+	key  []byte
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+type storeFile struct {
+	Version int      `json:"version"`
+	Entries []*Entry `json:"entries"`
+}
+
+// KeyFromEnv returns the fingerprint key from the environment
+func KeyFromEnv() ([]byte, error) {
+	key := os.Getenv(KeyEnv)
+	if key == "" {
+		return nil, fmt.Errorf("%s must be set to the fingerprint key", KeyEnv)
+	}
+	if len(key) < minKeyLength {
+		return nil, fmt.Errorf("%s must be at least %d characters long", KeyEnv, minKeyLength)
+	}
+	return []byte(key), nil
+}
+
+// Open reads the store at path, or returns an empty store when the file does not
+// exist yet
+func Open(path string, key []byte) (*Store, error) {
+	s := &Store{path: path, key: key, entries: make(map[string]*Entry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fingerprint store: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse fingerprint store %s: %w", path, err)
+	}
+	if file.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported fingerprint store version %d in %s", file.Version, path)
+	}
+	for _, entry := range file.Entries {
+		s.entries[entryKey(entry.Target, entry.Kind, entry.Name)] = entry
+	}
+	return s, nil
+}
+
+// Fingerprint returns the fingerprint of a secret's value
+func (s *Store) Fingerprint(target plan.Target, kind plan.Kind, name, value string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(entryKey(target, kind, name)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Record stores the fingerprint of a value just written and when GitHub updated it
+func (s *Store) Record(target plan.Target, kind plan.Kind, name, value string, updatedAt time.Time) {
+	entry := &Entry{
+		Target:      target,
+		Kind:        kind,
+		Name:        name,
+		Fingerprint: s.Fingerprint(target, kind, name, value),
+		UpdatedAt:   updatedAt.UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entryKey(target, kind, name)] = entry
+}
+
+// Remove forgets a secret
+func (s *Store) Remove(target plan.Target, kind plan.Kind, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, entryKey(target, kind, name))
+}
+
+// Lookup returns the entry of a secret, or nil if none was recorded
+func (s *Store) Lookup(target plan.Target, kind plan.Kind, name string) *Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[entryKey(target, kind, name)]
+}
+
+// Matches reports whether value has the fingerprint recorded in the entry
+func (s *Store) Matches(entry *Entry, value string) bool {
+	want := s.Fingerprint(entry.Target, entry.Kind, entry.Name, value)
+	return hmac.Equal([]byte(want), []byte(entry.Fingerprint))
+}
+
+// Entries returns the entries recorded for a target and kind, sorted by name
+func (s *Store) Entries(target plan.Target, kind plan.Kind) []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []*Entry
+	for _, entry := range s.entries {
+		if entry.Target == target && entry.Kind == kind {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b *Entry) int { return cmp.Compare(a.Name, b.Name) })
+	return entries
+}
+
+// Save writes the store back to its file. Entries are sorted so committed stores
+// produce small diffs.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	file := storeFile{Version: storeVersion, Entries: make([]*Entry, 0, len(s.entries))}
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		file.Entries = append(file.Entries, s.entries[key])
+	}
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fingerprint store: %w", err)
+	}
+	if err := os.WriteFile(s.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fingerprint store: %w", err)
+	}
+	return nil
+}
+
+// entryKey identifies a secret within the store
+func entryKey(target plan.Target, kind plan.Kind, name string) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%t|%s", kind, target.Org, target.Repo, target.Environment, target.User, name)
+}
@@ -0,0 +1,109 @@
+package fingerprint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gh-secrets-manager/pkg/plan"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	store, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	repo := plan.RepoTarget("owner/repo")
+	updatedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store.Record(repo, plan.KindSecret, "TOKEN", "s3cret", updatedAt)
+	store.Record(repo, plan.KindDependabotSecret, "TOKEN", "s3cret", updatedAt)
+	store.Record(plan.OrgTarget("owner"), plan.KindSecret, "OLD", "value", updatedAt)
+	store.Remove(plan.OrgTarget("owner"), plan.KindSecret, "OLD")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if strings.Contains(string(data), "s3cret") || strings.Contains(string(data), "OLD") {
+		t.Errorf("store = %s, want no values and no removed entries", data)
+	}
+
+	reopened, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	entry := reopened.Lookup(repo, plan.KindSecret, "TOKEN")
+	if entry == nil {
+		t.Fatal("Lookup returned nil after reopening")
+	}
+	if !entry.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("UpdatedAt = %v, want %v", entry.UpdatedAt, updatedAt)
+	}
+	if !reopened.Matches(entry, "s3cret") || reopened.Matches(entry, "other") {
+		t.Error("Matches does not tell the recorded value from another")
+	}
+	if got := reopened.Entries(repo, plan.KindSecret); len(got) != 1 {
+		t.Errorf("Entries = %v, want one Actions secret", got)
+	}
+}
+
+func TestFingerprintIsBoundToSecret(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "fingerprints.json"), testKey)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+
+	repo := plan.RepoTarget("owner/repo")
+	fingerprints := map[string]bool{
+		store.Fingerprint(repo, plan.KindSecret, "A", "value"):                           true,
+		store.Fingerprint(repo, plan.KindSecret, "B", "value"):                           true,
+		store.Fingerprint(repo, plan.KindDependabotSecret, "A", "value"):                 true,
+		store.Fingerprint(plan.RepoTarget("owner/other"), plan.KindSecret, "A", "value"): true,
+	}
+	if len(fingerprints) != 4 {
+		t.Errorf("equal values of different secrets share fingerprints: %v", fingerprints)
+	}
+
+	other, _ := Open(filepath.Join(t.TempDir(), "fingerprints.json"), []byte("another key of 32 bytes length!!"))
+	if other.Fingerprint(repo, plan.KindSecret, "A", "value") == store.Fingerprint(repo, plan.KindSecret, "A", "value") {
+		t.Error("fingerprints do not depend on the key")
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"invalid.json": "{",
+		"version.json": `{"version": 2, "entries": []}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := Open(path, testKey); err == nil {
+			t.Errorf("Open(%s) returned nil error", name)
+		}
+	}
+}
+
+func TestKeyFromEnv(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	if _, err := KeyFromEnv(); err == nil {
+		t.Error("KeyFromEnv returned nil error without a key")
+	}
+	t.Setenv(KeyEnv, "short")
+	if _, err := KeyFromEnv(); err == nil {
+		t.Error("KeyFromEnv returned nil error for a short key")
+	}
+	t.Setenv(KeyEnv, string(testKey))
+	if key, err := KeyFromEnv(); err != nil || string(key) != string(testKey) {
+		t.Errorf("KeyFromEnv = %q, %v, want the key", key, err)
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"strings"
 )
 
-// SecretData represents a secret or variable entry from a file
+// SecretData represents a secret or variable entry from a file.
+// KeyID is set on secrets whose Value was already encrypted with that public key.
 type SecretData struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
+	KeyID string `json:"key_id,omitempty"`
 }
 
 // ReadJSONSecrets reads secrets from a JSON file
@@ -0,0 +1,297 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gh-secrets-manager/pkg/plan"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes the desired secrets, variables and Dependabot secrets
+// for a set of organizations, repositories and environments.
+//
+// A nil map means the entries of that kind are not managed by the manifest,
+// while an empty map means the scope should have no entries of that kind.
+type Manifest struct {
+	Organizations []Organization `yaml:"organizations"`
+	Repositories  []Repository   `yaml:"repositories"`
+}
+
+// Organization is the desired state of an organization
+type Organization struct {
+	Name       string            `yaml:"name"`
+	Secrets    map[string]string `yaml:"secrets"`
+	Variables  map[string]string `yaml:"variables"`
+	Dependabot map[string]string `yaml:"dependabot"`
+}
+
+// Repository is the desired state of a repository in owner/repo form
+type Repository struct {
+	Name         string            `yaml:"name"`
+	Secrets      map[string]string `yaml:"secrets"`
+	Variables    map[string]string `yaml:"variables"`
+	Dependabot   map[string]string `yaml:"dependabot"`
+	Environments []Environment     `yaml:"environments"`
+}
+
+// Environment is the desired state of a repository environment
+type Environment struct {
+	Name      string            `yaml:"name"`
+	Secrets   map[string]string `yaml:"secrets"`
+	Variables map[string]string `yaml:"variables"`
+}
+
+// Desired is the desired state of a single target
+type Desired struct {
+	Target     plan.Target
+	Secrets    map[string]string
+	Variables  map[string]string
+	Dependabot map[string]string
+}
+
+// State is the current state of a single target as reported by GitHub.
+// Secret values cannot be read back, so only their names are known.
+type State struct {
+	Secrets    map[string]bool
+	Variables  map[string]string
+	Dependabot map[string]bool
+}
+
+// Load reads a manifest from a YAML file. Secret values may reference
+// environment variables using ${NAME} so that they need not be committed;
+// $$ stands for a literal $.
+func Load(filePath string) (*Manifest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates a manifest
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := m.expandSecrets(); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	orgs := make(map[string]bool)
+	for _, org := range m.Organizations {
+		if org.Name == "" {
+			return fmt.Errorf("organization name cannot be empty")
+		}
+		if orgs[org.Name] {
+			return fmt.Errorf("organization %s is listed more than once", org.Name)
+		}
+		orgs[org.Name] = true
+	}
+
+	repos := make(map[string]bool)
+	for _, repo := range m.Repositories {
+		if owner, name, ok := strings.Cut(repo.Name, "/"); !ok || owner == "" || name == "" {
+			return fmt.Errorf("repository %q must be in owner/repo form", repo.Name)
+		}
+		if repos[repo.Name] {
+			return fmt.Errorf("repository %s is listed more than once", repo.Name)
+		}
+		repos[repo.Name] = true
+
+		envs := make(map[string]bool)
+		for _, env := range repo.Environments {
+			if env.Name == "" {
+				return fmt.Errorf("environment name cannot be empty in repository %s", repo.Name)
+			}
+			if envs[env.Name] {
+				return fmt.Errorf("environment %s is listed more than once in repository %s", env.Name, repo.Name)
+			}
+			envs[env.Name] = true
+		}
+	}
+
+	return nil
+}
+
+func (m *Manifest) expandSecrets() error {
+	for _, org := range m.Organizations {
+		if err := expandValues(org.Secrets); err != nil {
+			return fmt.Errorf("organization %s: %w", org.Name, err)
+		}
+		if err := expandValues(org.Dependabot); err != nil {
+			return fmt.Errorf("organization %s: %w", org.Name, err)
+		}
+	}
+	for _, repo := range m.Repositories {
+		if err := expandValues(repo.Secrets); err != nil {
+			return fmt.Errorf("repository %s: %w", repo.Name, err)
+		}
+		if err := expandValues(repo.Dependabot); err != nil {
+			return fmt.Errorf("repository %s: %w", repo.Name, err)
+		}
+		for _, env := range repo.Environments {
+			if err := expandValues(env.Secrets); err != nil {
+				return fmt.Errorf("repository %s environment %s: %w", repo.Name, env.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expandValues replaces ${NAME} references with environment variable values in place.
+// $$ stands for a literal $, and a $ not followed by { or $ is kept as is.
+func expandValues(values map[string]string) error {
+	for name, value := range values {
+		expanded, err := expandValue(value)
+		if err != nil {
+			return fmt.Errorf("secret %s %w", name, err)
+		}
+		values[name] = expanded
+	}
+	return nil
+}
+
+func expandValue(value string) (string, error) {
+	var b strings.Builder
+	var missing []string
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] != '$' || i+1 == len(value):
+			b.WriteByte(value[i])
+		case value[i+1] == '$':
+			b.WriteByte('$')
+			i++
+		case value[i+1] == '{':
+			end := strings.IndexByte(value[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("has an unterminated ${ reference")
+			}
+			key := value[i+2 : i+2+end]
+			v, ok := os.LookupEnv(key)
+			if !ok {
+				missing = append(missing, key)
+			}
+			b.WriteString(v)
+			i += end + 2
+		default:
+			b.WriteByte('$')
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("references unset environment variable %s", strings.Join(missing, ", "))
+	}
+	return b.String(), nil
+}
+
+// Desired returns the desired state of every target in the manifest
+func (m *Manifest) Desired() []Desired {
+	var desired []Desired
+	for _, org := range m.Organizations {
+		desired = append(desired, Desired{
+			Target:     plan.OrgTarget(org.Name),
+			Secrets:    org.Secrets,
+			Variables:  org.Variables,
+			Dependabot: org.Dependabot,
+		})
+	}
+	for _, repo := range m.Repositories {
+		desired = append(desired, Desired{
+			Target:     plan.RepoTarget(repo.Name),
+			Secrets:    repo.Secrets,
+			Variables:  repo.Variables,
+			Dependabot: repo.Dependabot,
+		})
+		for _, env := range repo.Environments {
+			desired = append(desired, Desired{
+				Target:    plan.EnvironmentTarget(repo.Name, env.Name),
+				Secrets:   env.Secrets,
+				Variables: env.Variables,
+			})
+		}
+	}
+	return desired
+}
+
+// Diff returns the changes needed to bring the current state in line with the desired state.
+// Existing secrets are always updated since their values cannot be compared. Entries missing
+// from the manifest are only deleted when prune is set and the manifest manages that kind.
+func Diff(d Desired, s State, prune bool) []plan.Change {
+	var changes []plan.Change
+	changes = append(changes, diffSecrets(d.Target, plan.KindSecret, d.Secrets, s.Secrets, prune)...)
+	changes = append(changes, diffVariables(d.Target, d.Variables, s.Variables, prune)...)
+	changes = append(changes, diffSecrets(d.Target, plan.KindDependabotSecret, d.Dependabot, s.Dependabot, prune)...)
+	return changes
+}
+
+func diffSecrets(target plan.Target, kind plan.Kind, desired map[string]string, current map[string]bool, prune bool) []plan.Change {
+	if desired == nil {
+		return nil
+	}
+
+	var changes []plan.Change
+	for _, name := range sortedKeys(desired) {
+		action := plan.ActionCreate
+		if current[name] {
+			action = plan.ActionUpdate
+		}
+		changes = append(changes, plan.Change{Target: target, Kind: kind, Name: name, Action: action, Value: desired[name]})
+	}
+
+	if prune {
+		for _, name := range sortedKeys(current) {
+			if _, ok := desired[name]; !ok {
+				changes = append(changes, plan.Change{Target: target, Kind: kind, Name: name, Action: plan.ActionDelete})
+			}
+		}
+	}
+	return changes
+}
+
+func diffVariables(target plan.Target, desired, current map[string]string, prune bool) []plan.Change {
+	if desired == nil {
+		return nil
+	}
+
+	var changes []plan.Change
+	for _, name := range sortedKeys(desired) {
+		value, ok := current[name]
+		switch {
+		case !ok:
+			changes = append(changes, plan.Change{Target: target, Kind: plan.KindVariable, Name: name, Action: plan.ActionCreate, Value: desired[name]})
+		case value != desired[name]:
+			changes = append(changes, plan.Change{Target: target, Kind: plan.KindVariable, Name: name, Action: plan.ActionUpdate, Value: desired[name]})
+		}
+	}
+
+	if prune {
+		for _, name := range sortedKeys(current) {
+			if _, ok := desired[name]; !ok {
+				changes = append(changes, plan.Change{Target: target, Kind: plan.KindVariable, Name: name, Action: plan.ActionDelete})
+			}
+		}
+	}
+	return changes
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gh-secrets-manager/pkg/plan"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "s3cret")
+
+	content := `
+organizations:
+  - name: myorg
+    secrets:
+      API_KEY: ${TEST_API_KEY}
+      PASSWORD: pa$word
+      PRICE: $${TEST_API_KEY} and $$5
+    variables:
+      LOG_LEVEL: $literal
+repositories:
+  - name: myorg/service
+    dependabot:
+      NPM_TOKEN: token
+    environments:
+      - name: prod
+        variables: {}
+`
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if got := m.Organizations[0].Secrets["API_KEY"]; got != "s3cret" {
+		t.Errorf("API_KEY = %q, want expanded value %q", got, "s3cret")
+	}
+	if got := m.Organizations[0].Secrets["PASSWORD"]; got != "pa$word" {
+		t.Errorf("PASSWORD = %q, want a bare $ kept literally", got)
+	}
+	if got := m.Organizations[0].Secrets["PRICE"]; got != "${TEST_API_KEY} and $5" {
+		t.Errorf("PRICE = %q, want $$ unescaped to $", got)
+	}
+	if got := m.Organizations[0].Variables["LOG_LEVEL"]; got != "$literal" {
+		t.Errorf("LOG_LEVEL = %q, want variables left unexpanded", got)
+	}
+
+	desired := m.Desired()
+	wantTargets := []plan.Target{
+		plan.OrgTarget("myorg"),
+		plan.RepoTarget("myorg/service"),
+		plan.EnvironmentTarget("myorg/service", "prod"),
+	}
+	if len(desired) != len(wantTargets) {
+		t.Fatalf("Desired returned %d targets, want %d", len(desired), len(wantTargets))
+	}
+	for i, d := range desired {
+		if d.Target != wantTargets[i] {
+			t.Errorf("target %d = %v, want %v", i, d.Target, wantTargets[i])
+		}
+	}
+
+	env := desired[2]
+	if env.Variables == nil || len(env.Variables) != 0 {
+		t.Errorf("expected empty but managed variables for environment, got %v", env.Variables)
+	}
+	if env.Secrets != nil {
+		t.Errorf("expected unmanaged secrets for environment, got %v", env.Secrets)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		match   string
+	}{
+		{"invalid repo name", "repositories:\n  - name: service\n", "owner/repo"},
+		{"duplicate org", "organizations:\n  - name: a\n  - name: a\n", "more than once"},
+		{"empty environment", "repositories:\n  - name: o/r\n    environments:\n      - name: \"\"\n", "environment name"},
+		{"unset variable", "organizations:\n  - name: a\n    secrets:\n      KEY: ${GH_SM_TEST_UNSET}\n", "GH_SM_TEST_UNSET"},
+		{"unterminated reference", "organizations:\n  - name: a\n    secrets:\n      KEY: ${GH_SM_TEST_UNSET\n", "unterminated"},
+		{"bad yaml", "organizations: [", "failed to parse"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.content))
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !strings.Contains(err.Error(), tc.match) {
+				t.Errorf("Expected error containing %q, got %v", tc.match, err)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	target := plan.RepoTarget("o/r")
+	desired := Desired{
+		Target:    target,
+		Secrets:   map[string]string{"NEW": "1", "EXISTING": "2"},
+		Variables: map[string]string{"SAME": "a", "CHANGED": "b", "ADDED": "c"},
+	}
+	state := State{
+		Secrets:    map[string]bool{"EXISTING": true, "STALE": true},
+		Variables:  map[string]string{"SAME": "a", "CHANGED": "old", "EXTRA": "x"},
+		Dependabot: map[string]bool{"UNMANAGED": true},
+	}
+
+	summarize := func(changes []plan.Change) []string {
+		var out []string
+		for _, c := range changes {
+			out = append(out, string(c.Action)+" "+string(c.Kind)+" "+c.Name)
+		}
+		return out
+	}
+
+	got := summarize(Diff(desired, state, false))
+	want := []string{
+		"update secret EXISTING",
+		"create secret NEW",
+		"create variable ADDED",
+		"update variable CHANGED",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff without prune = %v, want %v", got, want)
+	}
+
+	got = summarize(Diff(desired, state, true))
+	want = []string{
+		"update secret EXISTING",
+		"create secret NEW",
+		"delete secret STALE",
+		"create variable ADDED",
+		"update variable CHANGED",
+		"delete variable EXTRA",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff with prune = %v, want %v", got, want)
+	}
+}
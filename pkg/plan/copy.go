@@ -0,0 +1,120 @@
+package plan
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// CopyOptions selects the entries Copy copies and supplies the values of secrets,
+// which cannot be read back from their source
+type CopyOptions struct {
+	// Names limits the copy to these entries; every entry is copied when it is empty
+	Names []string
+	// Exclude leaves these entries out
+	Exclude []string
+	// SecretValues holds secret values by name
+	SecretValues map[string]string
+	// SecretsFromEnv reads the value of a secret missing from SecretValues from the
+	// environment variable of the same name
+	SecretsFromEnv bool
+	// Access is set on every change. It only applies to, and is required for, copies
+	// to an organization.
+	Access *Access
+}
+
+// HasSecretValues reports whether the options supply secret values
+func (o CopyOptions) HasSecretValues() bool {
+	return o.SecretValues != nil || o.SecretsFromEnv
+}
+
+// CheckAccess checks that access is given exactly when copying to an organization.
+// Organization entries created without one would be visible to all its repositories.
+func (o CopyOptions) CheckAccess(to Target) error {
+	switch {
+	case o.Access != nil && !to.IsOrg():
+		return fmt.Errorf("--visibility and --selected-repos only apply when --to is an organization")
+	case o.Access == nil && to.IsOrg():
+		return fmt.Errorf("--visibility is required when --to is an organization, to choose which of its repositories can use the copies")
+	}
+	return nil
+}
+
+func (o CopyOptions) secretValue(name string) (string, bool) {
+	if value, ok := o.SecretValues[name]; ok {
+		return value, true
+	}
+	if o.SecretsFromEnv {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// CopyKinds returns the kinds named by a --kind flag for a copy. When none are named,
+// variables are copied, and Actions secrets too when secret values are supplied.
+// Secret kinds cannot be copied without values.
+func CopyKinds(names []string, from, to Target, haveValues bool) ([]Kind, error) {
+	if len(names) == 0 {
+		if haveValues {
+			return []Kind{KindVariable, KindSecret}, nil
+		}
+		return []Kind{KindVariable}, nil
+	}
+
+	kinds, err := ScopeKinds(names, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !haveValues && slices.ContainsFunc(kinds, func(kind Kind) bool { return kind != KindVariable }) {
+		return nil, fmt.Errorf("secret values cannot be read from GitHub; supply them with --secrets-file or --secrets-from-env")
+	}
+	return kinds, nil
+}
+
+// Copy plans setting the entries of each kind at from, as found by lookup, on to.
+// Variables keep their values and secrets take theirs from the options. Secrets
+// without a value are left out of the plan and returned as missing, each described
+// as "kind name". Every name the options list must exist at from in one of the kinds.
+func Copy(lookup Lookup, from, to Target, kinds []Kind, opts CopyOptions) (*Plan, []string, error) {
+	p := &Plan{}
+	var missing []string
+	found := make(map[string]bool)
+	for _, kind := range kinds {
+		entries, err := lookup(from, kind)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to look up %s entries in %s: %w", kind, from, err)
+		}
+
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			if (len(opts.Names) > 0 && !slices.Contains(opts.Names, name)) || slices.Contains(opts.Exclude, name) {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			found[name] = true
+			value := entries[name]
+			if kind != KindVariable {
+				var ok bool
+				if value, ok = opts.secretValue(name); !ok {
+					missing = append(missing, fmt.Sprintf("%s %s", kind, name))
+					continue
+				}
+			}
+			p.Add(Change{Target: to, Kind: kind, Name: name, Action: ActionSet, Value: value, Access: opts.Access})
+		}
+	}
+
+	for _, name := range opts.Names {
+		if !found[name] {
+			return nil, nil, fmt.Errorf("%s not found in %s", name, from)
+		}
+	}
+	return p, missing, nil
+}
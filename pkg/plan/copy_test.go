@@ -0,0 +1,152 @@
+package plan
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	copyFrom = EnvironmentTarget("owner/repo", "staging")
+	copyTo   = EnvironmentTarget("owner/repo", "production")
+)
+
+func copyLookup(target Target, kind Kind) (map[string]string, error) {
+	if target != copyFrom {
+		return nil, errors.New("unexpected target")
+	}
+	if kind == KindVariable {
+		return map[string]string{"URL": "https://staging", "LOG_LEVEL": "debug", "DEBUG": "1"}, nil
+	}
+	return map[string]string{"API_KEY": "", "DB_PASSWORD": ""}, nil
+}
+
+func changeNames(p *Plan) []string {
+	var names []string
+	for _, c := range p.Changes {
+		names = append(names, string(c.Kind)+" "+c.Name+"="+c.Value)
+	}
+	return names
+}
+
+func TestCopy(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "from-env")
+
+	p, missing, err := Copy(copyLookup, copyFrom, copyTo, []Kind{KindVariable, KindSecret}, CopyOptions{
+		Exclude:        []string{"DEBUG"},
+		SecretValues:   map[string]string{"API_KEY": "from-file"},
+		SecretsFromEnv: true,
+	})
+	if err != nil {
+		t.Fatalf("Copy returned error: %v", err)
+	}
+	want := []string{
+		"variable LOG_LEVEL=debug",
+		"variable URL=https://staging",
+		"secret API_KEY=from-file",
+		"secret DB_PASSWORD=from-env",
+	}
+	if got := changeNames(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+	if len(missing) != 0 {
+		t.Errorf("missing = %v, want none", missing)
+	}
+	for _, c := range p.Changes {
+		if c.Target != copyTo || c.Action != ActionSet {
+			t.Errorf("change %v, want a set in %v", c, copyTo)
+		}
+	}
+}
+
+func TestCopyMissingSecrets(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "")
+
+	p, missing, err := Copy(copyLookup, copyFrom, copyTo, []Kind{KindSecret}, CopyOptions{
+		SecretValues:   map[string]string{"API_KEY": "value"},
+		SecretsFromEnv: true,
+	})
+	if err != nil {
+		t.Fatalf("Copy returned error: %v", err)
+	}
+	if got := changeNames(p); !reflect.DeepEqual(got, []string{"secret API_KEY=value"}) {
+		t.Errorf("changes = %v, want only API_KEY", got)
+	}
+	if !reflect.DeepEqual(missing, []string{"secret DB_PASSWORD"}) {
+		t.Errorf("missing = %v, want the secret with an empty environment variable", missing)
+	}
+}
+
+func TestCopyNames(t *testing.T) {
+	access := &Access{Visibility: "private"}
+	p, _, err := Copy(copyLookup, copyFrom, OrgTarget("owner"), []Kind{KindVariable, KindSecret}, CopyOptions{
+		Names:        []string{"URL", "API_KEY"},
+		SecretValues: map[string]string{"API_KEY": "value"},
+		Access:       access,
+	})
+	if err != nil {
+		t.Fatalf("Copy returned error: %v", err)
+	}
+	if got := changeNames(p); !reflect.DeepEqual(got, []string{"variable URL=https://staging", "secret API_KEY=value"}) {
+		t.Errorf("changes = %v, want URL and API_KEY", got)
+	}
+	if p.Changes[0].Access != access {
+		t.Errorf("Access = %v, want %v", p.Changes[0].Access, access)
+	}
+
+	_, _, err = Copy(copyLookup, copyFrom, copyTo, []Kind{KindVariable}, CopyOptions{Names: []string{"URL", "API_KEY"}})
+	if err == nil || !strings.Contains(err.Error(), "API_KEY not found") {
+		t.Errorf("Copy error = %v, want API_KEY not found among the kinds copied", err)
+	}
+
+	_, _, err = Copy(copyLookup, copyFrom, copyTo, []Kind{KindVariable}, CopyOptions{Names: []string{"URL"}, Exclude: []string{"URL"}})
+	if err == nil || !strings.Contains(err.Error(), "URL not found") {
+		t.Errorf("Copy error = %v, want an excluded name reported as not found", err)
+	}
+}
+
+func TestCopyLookupError(t *testing.T) {
+	_, _, err := Copy(copyLookup, RepoTarget("owner/other"), copyTo, []Kind{KindVariable}, CopyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "owner/other") {
+		t.Errorf("Copy error = %v, want lookup failure for owner/other", err)
+	}
+}
+
+func TestCopyKinds(t *testing.T) {
+	tests := []struct {
+		names      []string
+		haveValues bool
+		want       []Kind
+		err        bool
+	}{
+		{nil, false, []Kind{KindVariable}, false},
+		{nil, true, []Kind{KindVariable, KindSecret}, false},
+		{[]string{"variables"}, false, []Kind{KindVariable}, false},
+		{[]string{"secrets"}, false, nil, true},
+		{[]string{"dependabot"}, true, []Kind{KindDependabotSecret}, false},
+	}
+
+	for _, tc := range tests {
+		got, err := CopyKinds(tc.names, RepoTarget("owner/a"), RepoTarget("owner/b"), tc.haveValues)
+		if (err != nil) != tc.err || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("CopyKinds(%v, %v) = %v, %v, want %v (error %v)", tc.names, tc.haveValues, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestCopyCheckAccess(t *testing.T) {
+	access := &Access{Visibility: "all"}
+	if err := (CopyOptions{}).CheckAccess(OrgTarget("myorg")); err == nil || !strings.Contains(err.Error(), "--visibility is required") {
+		t.Errorf("CheckAccess error = %v, want visibility required for an organization", err)
+	}
+	if err := (CopyOptions{Access: access}).CheckAccess(OrgTarget("myorg")); err != nil {
+		t.Errorf("CheckAccess returned error: %v", err)
+	}
+	if err := (CopyOptions{Access: access}).CheckAccess(RepoTarget("owner/repo")); err == nil {
+		t.Error("CheckAccess returned nil error for access on a repository")
+	}
+	if err := (CopyOptions{}).CheckAccess(RepoTarget("owner/repo")); err != nil {
+		t.Errorf("CheckAccess returned error: %v", err)
+	}
+}
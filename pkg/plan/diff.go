@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// DiffStatus describes how an entry differs between two targets
+type DiffStatus string
+
+const (
+	// DiffMissing is an entry of the first target that the second lacks
+	DiffMissing DiffStatus = "missing"
+	// DiffExtra is an entry of the second target that the first lacks
+	DiffExtra DiffStatus = "extra"
+	// DiffChanged is a variable whose value differs between the targets
+	DiffChanged DiffStatus = "changed"
+)
+
+// Difference is an entry that differs between two targets. Values are only set for
+// variables, since secret values cannot be read back.
+type Difference struct {
+	Kind      Kind       `json:"kind"`
+	Name      string     `json:"name"`
+	Status    DiffStatus `json:"status"`
+	FromValue string     `json:"from_value,omitempty"`
+	ToValue   string     `json:"to_value,omitempty"`
+}
+
+// Diff compares the entries of each kind at two targets. Secrets are compared by
+// name and variables by name and value. Differences are ordered by kind, in the
+// order given, then by name.
+func Diff(lookup Lookup, from, to Target, kinds ...Kind) ([]Difference, error) {
+	var diffs []Difference
+	for _, kind := range kinds {
+		fromEntries, err := lookup(from, kind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up %s entries in %s: %w", kind, from, err)
+		}
+		toEntries, err := lookup(to, kind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up %s entries in %s: %w", kind, to, err)
+		}
+
+		var kindDiffs []Difference
+		for name, fromValue := range fromEntries {
+			toValue, exists := toEntries[name]
+			switch {
+			case !exists:
+				kindDiffs = append(kindDiffs, Difference{Kind: kind, Name: name, Status: DiffMissing, FromValue: fromValue})
+			case kind == KindVariable && fromValue != toValue:
+				kindDiffs = append(kindDiffs, Difference{Kind: kind, Name: name, Status: DiffChanged, FromValue: fromValue, ToValue: toValue})
+			}
+		}
+		for name, toValue := range toEntries {
+			if _, exists := fromEntries[name]; !exists {
+				kindDiffs = append(kindDiffs, Difference{Kind: kind, Name: name, Status: DiffExtra, ToValue: toValue})
+			}
+		}
+		slices.SortFunc(kindDiffs, func(a, b Difference) int { return cmp.Compare(a.Name, b.Name) })
+		diffs = append(diffs, kindDiffs...)
+	}
+	return diffs, nil
+}
+
+// WriteDiff writes differences between two targets in a diff-like format: entries
+// missing from the second target are prefixed with -, extra entries with + and
+// changed variables with ~
+func WriteDiff(w io.Writer, from, to Target, diffs []Difference) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintf(w, "No differences between %s and %s.\n", from, to)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", from, to); err != nil {
+		return err
+	}
+	counts := make(map[DiffStatus]int)
+	for _, d := range diffs {
+		counts[d.Status]++
+		var err error
+		switch d.Status {
+		case DiffMissing:
+			_, err = fmt.Fprintf(w, "- %s %s\n", d.Kind, d.Name)
+		case DiffExtra:
+			_, err = fmt.Fprintf(w, "+ %s %s\n", d.Kind, d.Name)
+		case DiffChanged:
+			_, err = fmt.Fprintf(w, "~ %s %s: %q -> %q\n", d.Kind, d.Name, d.FromValue, d.ToValue)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nDiff: %d missing, %d extra, %d changed.\n",
+		counts[DiffMissing], counts[DiffExtra], counts[DiffChanged])
+	return err
+}
@@ -0,0 +1,87 @@
+package plan
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	staging := EnvironmentTarget("owner/repo", "staging")
+	production := EnvironmentTarget("owner/repo", "production")
+	entries := map[Target]map[Kind]map[string]string{
+		staging: {
+			KindSecret:   {"API_KEY": "", "DB_PASSWORD": ""},
+			KindVariable: {"LOG_LEVEL": "debug", "REGION": "eu", "URL": "https://staging"},
+		},
+		production: {
+			KindSecret:   {"API_KEY": "", "PAGER_TOKEN": ""},
+			KindVariable: {"LOG_LEVEL": "info", "REGION": "eu"},
+		},
+	}
+	lookup := func(target Target, kind Kind) (map[string]string, error) {
+		return entries[target][kind], nil
+	}
+
+	diffs, err := Diff(lookup, staging, production, KindVariable, KindSecret)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+	want := []Difference{
+		{Kind: KindVariable, Name: "LOG_LEVEL", Status: DiffChanged, FromValue: "debug", ToValue: "info"},
+		{Kind: KindVariable, Name: "URL", Status: DiffMissing, FromValue: "https://staging"},
+		{Kind: KindSecret, Name: "DB_PASSWORD", Status: DiffMissing},
+		{Kind: KindSecret, Name: "PAGER_TOKEN", Status: DiffExtra},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Diff = %+v, want %+v", diffs, want)
+	}
+
+	var out strings.Builder
+	if err := WriteDiff(&out, staging, production, diffs); err != nil {
+		t.Fatalf("WriteDiff returned error: %v", err)
+	}
+	for _, line := range []string{
+		"--- owner/repo (environment staging)",
+		"+++ owner/repo (environment production)",
+		`~ variable LOG_LEVEL: "debug" -> "info"`,
+		"- variable URL",
+		"- secret DB_PASSWORD",
+		"+ secret PAGER_TOKEN",
+		"Diff: 2 missing, 1 extra, 1 changed.",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("WriteDiff output missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	lookup := func(target Target, kind Kind) (map[string]string, error) {
+		return map[string]string{"A": "1"}, nil
+	}
+	diffs, err := Diff(lookup, RepoTarget("owner/a"), OrgTarget("owner"), KindVariable, KindSecret)
+	if err != nil || len(diffs) != 0 {
+		t.Fatalf("Diff = %v, %v, want no differences", diffs, err)
+	}
+
+	var out strings.Builder
+	WriteDiff(&out, RepoTarget("owner/a"), OrgTarget("owner"), diffs)
+	if out.String() != "No differences between owner/a and org owner.\n" {
+		t.Errorf("WriteDiff = %q", out.String())
+	}
+}
+
+func TestDiffLookupError(t *testing.T) {
+	lookup := func(target Target, kind Kind) (map[string]string, error) {
+		if target.Repo == "owner/b" {
+			return nil, errors.New("not found")
+		}
+		return map[string]string{}, nil
+	}
+	_, err := Diff(lookup, RepoTarget("owner/a"), RepoTarget("owner/b"), KindSecret)
+	if err == nil || !strings.Contains(err.Error(), "owner/b") {
+		t.Errorf("Diff error = %v, want lookup failure for owner/b", err)
+	}
+}
@@ -0,0 +1,147 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Status is the outcome of executing a change
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Result records the outcome of executing a single change
+type Result struct {
+	Change
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Err    error  `json:"-"`
+}
+
+// Results holds the outcome of every change in a plan, in plan order
+type Results []Result
+
+// Apply performs a single change
+type Apply func(Change) error
+
+// Execute performs every change in the plan using up to concurrency workers. Changes
+// to the same target run in plan order on one worker, so only different targets are
+// processed in parallel. A failed change does not stop the remaining changes.
+func (p *Plan) Execute(concurrency int, apply Apply) Results {
+	results := make(Results, len(p.Changes))
+
+	var order []Target
+	byTarget := make(map[Target][]int)
+	for i, c := range p.Changes {
+		if _, ok := byTarget[c.Target]; !ok {
+			order = append(order, c.Target)
+		}
+		byTarget[c.Target] = append(byTarget[c.Target], i)
+	}
+
+	targets := make(chan Target)
+	var wg sync.WaitGroup
+	for range max(1, min(concurrency, len(order))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range targets {
+				for _, i := range byTarget[target] {
+					results[i] = run(p.Changes[i], apply)
+				}
+			}
+		}()
+	}
+
+	for _, target := range order {
+		targets <- target
+	}
+	close(targets)
+	wg.Wait()
+
+	return results
+}
+
+func run(c Change, apply Apply) Result {
+	if err := apply(c); err != nil {
+		return Result{Change: c, Status: StatusFailed, Error: err.Error(), Err: err}
+	}
+	return Result{Change: c, Status: StatusSucceeded}
+}
+
+// Retry returns a plan of the changes that failed in the given results, matched by
+// target, kind and name so set changes match the creates and updates they became
+func (p *Plan) Retry(results Results) *Plan {
+	type key struct {
+		target Target
+		kind   Kind
+		name   string
+	}
+	failed := make(map[key]bool)
+	for _, result := range results.Failed() {
+		failed[key{result.Target, result.Kind, result.Name}] = true
+	}
+
+	retry := &Plan{}
+	for _, c := range p.Changes {
+		if failed[key{c.Target, c.Kind, c.Name}] {
+			retry.Add(c)
+		}
+	}
+	return retry
+}
+
+// Count returns the number of results with the given status
+func (r Results) Count(status Status) int {
+	n := 0
+	for _, result := range r {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Targets returns the number of distinct targets the results cover
+func (r Results) Targets() int {
+	seen := make(map[Target]bool)
+	for _, result := range r {
+		seen[result.Target] = true
+	}
+	return len(seen)
+}
+
+// Failed returns the results of the changes that failed
+func (r Results) Failed() Results {
+	var failed Results
+	for _, result := range r {
+		if result.Status == StatusFailed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns an error describing how many changes failed, or nil if all succeeded
+func (r Results) Err() error {
+	if n := r.Count(StatusFailed); n > 0 {
+		return fmt.Errorf("%d of %d changes failed", n, len(r))
+	}
+	return nil
+}
+
+// WriteSummary writes each failed change followed by a count of outcomes
+func (r Results) WriteSummary(w io.Writer) error {
+	for _, result := range r.Failed() {
+		if _, err := fmt.Fprintf(w, "Failed to %s: %s\n", result.Change, result.Error); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Summary: %d succeeded, %d failed across %d targets.\n",
+		r.Count(StatusSucceeded), r.Count(StatusFailed), r.Targets())
+	return err
+}
@@ -0,0 +1,123 @@
+package plan
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecute(t *testing.T) {
+	p := &Plan{}
+	for _, repo := range []string{"o/a", "o/b", "o/c", "o/d", "o/e"} {
+		p.Add(
+			Change{Target: RepoTarget(repo), Kind: KindSecret, Name: "FIRST", Action: ActionSet},
+			Change{Target: RepoTarget(repo), Kind: KindSecret, Name: "SECOND", Action: ActionSet},
+		)
+	}
+
+	var mu sync.Mutex
+	applied := make(map[Target][]string)
+	var running, peak atomic.Int32
+
+	results := p.Execute(2, func(c Change) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		applied[c.Target] = append(applied[c.Target], c.Name)
+		mu.Unlock()
+
+		if c.Target == RepoTarget("o/c") && c.Name == "SECOND" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("ran %d changes at once, want at most 2", got)
+	}
+	for target, names := range applied {
+		if len(names) != 2 || names[0] != "FIRST" || names[1] != "SECOND" {
+			t.Errorf("changes for %s ran as %v, want plan order", target, names)
+		}
+	}
+
+	if len(results) != len(p.Changes) {
+		t.Fatalf("got %d results, want %d", len(results), len(p.Changes))
+	}
+	for i, result := range results {
+		if result.Change != p.Changes[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.Change, p.Changes[i])
+		}
+	}
+
+	if results.Count(StatusSucceeded) != 9 || results.Count(StatusFailed) != 1 {
+		t.Errorf("got %d succeeded and %d failed, want 9 and 1",
+			results.Count(StatusSucceeded), results.Count(StatusFailed))
+	}
+	if results.Err() == nil {
+		t.Error("Err() = nil, want an error for the failed change")
+	}
+}
+
+func TestResultsWriteSummary(t *testing.T) {
+	results := Results{
+		{Change: Change{Target: RepoTarget("o/a"), Kind: KindVariable, Name: "ENV", Action: ActionSet}, Status: StatusSucceeded},
+		{Change: Change{Target: RepoTarget("o/b"), Kind: KindVariable, Name: "ENV", Action: ActionSet}, Status: StatusFailed, Error: "not found"},
+	}
+
+	var buf bytes.Buffer
+	if err := results.WriteSummary(&buf); err != nil {
+		t.Fatalf("WriteSummary returned error: %v", err)
+	}
+
+	want := "Failed to set variable ENV in o/b: not found\nSummary: 1 succeeded, 1 failed across 2 targets.\n"
+	if buf.String() != want {
+		t.Errorf("WriteSummary =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestExecuteEmptyPlan(t *testing.T) {
+	results := (&Plan{}).Execute(4, func(Change) error {
+		t.Error("apply called for an empty plan")
+		return nil
+	})
+	if len(results) != 0 || results.Err() != nil {
+		t.Errorf("Execute on an empty plan = %v, %v", results, results.Err())
+	}
+}
+
+func TestPlanRetry(t *testing.T) {
+	p := &Plan{}
+	p.Add(
+		Change{Target: RepoTarget("o/a"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet, Value: "v"},
+		Change{Target: RepoTarget("o/b"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet, Value: "v"},
+		Change{Target: RepoTarget("o/b"), Kind: KindVariable, Name: "API_KEY", Action: ActionSet, Value: "v"},
+		Change{Target: RepoTarget("o/c"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet, Value: "v"},
+	)
+
+	results := Results{
+		{Change: Change{Target: RepoTarget("o/a"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet}, Status: StatusSucceeded},
+		{Change: Change{Target: RepoTarget("o/b"), Kind: KindSecret, Name: "API_KEY", Action: ActionUpdate}, Status: StatusFailed, Error: "502 Bad Gateway"},
+		{Change: Change{Target: RepoTarget("o/b"), Kind: KindVariable, Name: "API_KEY", Action: ActionSet}, Status: StatusSucceeded},
+		{Change: Change{Target: RepoTarget("o/c"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet}, Status: StatusFailed, Error: "timeout"},
+	}
+
+	retry := p.Retry(results)
+	if len(retry.Changes) != 2 || retry.Changes[0] != p.Changes[1] || retry.Changes[1] != p.Changes[3] {
+		t.Fatalf("Retry = %v, want the secret changes for o/b and o/c", retry.Changes)
+	}
+	if retry.Changes[0].Value != "v" {
+		t.Error("Retry dropped the change value")
+	}
+}
@@ -0,0 +1,263 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kind identifies the type of entry a change applies to
+type Kind string
+
+const (
+	KindSecret           Kind = "secret"
+	KindVariable         Kind = "variable"
+	KindDependabotSecret Kind = "dependabot"
+	KindCodespacesSecret Kind = "codespaces"
+)
+
+// Action describes what a change does to an entry
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+
+	// ActionSet creates or updates an entry without checking whether it exists
+	ActionSet Action = "set"
+)
+
+// Target identifies the organization, repository, environment or authenticated user a
+// change applies to. Repository targets use the owner/repo form; environment targets also
+// set Repo.
+type Target struct {
+	Org         string `json:"org,omitempty"`
+	Repo        string `json:"repo,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	User        bool   `json:"user,omitempty"`
+}
+
+// OrgTarget returns the target for an organization
+func OrgTarget(org string) Target {
+	return Target{Org: org}
+}
+
+// RepoTarget returns the target for a repository in owner/repo form
+func RepoTarget(repo string) Target {
+	return Target{Repo: repo}
+}
+
+// EnvironmentTarget returns the target for an environment of a repository
+func EnvironmentTarget(repo, environment string) Target {
+	return Target{Repo: repo, Environment: environment}
+}
+
+// UserTarget returns the target for the authenticated user, which only has Codespaces secrets
+func UserTarget() Target {
+	return Target{User: true}
+}
+
+// IsOrg reports whether the target is an organization
+func (t Target) IsOrg() bool {
+	return t.Repo == "" && t.Org != ""
+}
+
+// IsEnvironment reports whether the target is a repository environment
+func (t Target) IsEnvironment() bool {
+	return t.Repo != "" && t.Environment != ""
+}
+
+// SplitRepo returns the owner and name of a repository or environment target
+func (t Target) SplitRepo() (string, string) {
+	owner, name, ok := strings.Cut(t.Repo, "/")
+	if !ok {
+		return "", t.Repo
+	}
+	return owner, name
+}
+
+func (t Target) String() string {
+	switch {
+	case t.IsEnvironment():
+		return fmt.Sprintf("%s (environment %s)", t.Repo, t.Environment)
+	case t.Repo != "":
+		return t.Repo
+	case t.User:
+		return "user"
+	default:
+		return fmt.Sprintf("org %s", t.Org)
+	}
+}
+
+// Access scopes an organization secret or variable to a set of repositories.
+// SelectedRepos names repositories of the organization and applies to selected visibility.
+type Access struct {
+	Visibility    string   `json:"visibility"`
+	SelectedRepos []string `json:"selected_repositories,omitempty"`
+}
+
+// Change is a single create, update or delete of a secret or variable.
+// Value holds the desired value for create and update changes and is never serialized.
+// KeyID is set when Value is a secret already encrypted with that public key.
+// Access is only set for organization changes that restrict which repositories can use the entry.
+type Change struct {
+	Target Target  `json:"target"`
+	Kind   Kind    `json:"kind"`
+	Name   string  `json:"name"`
+	Action Action  `json:"action"`
+	Value  string  `json:"-"`
+	KeyID  string  `json:"-"`
+	Access *Access `json:"access,omitempty"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s %s in %s", c.Action, c.Kind, c.Name, c.Target)
+}
+
+// Plan is an ordered list of changes
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// New returns a plan containing the given changes
+func New(changes ...Change) *Plan {
+	return &Plan{Changes: changes}
+}
+
+// Add appends changes to the plan
+func (p *Plan) Add(changes ...Change) {
+	p.Changes = append(p.Changes, changes...)
+}
+
+// Empty reports whether the plan has no changes
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Count returns the number of changes with the given action
+func (p *Plan) Count(action Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary returns a one-line description of the number of changes by action
+func (p *Plan) Summary() string {
+	summary := fmt.Sprintf("Plan: %d to create, %d to update, %d to delete",
+		p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete))
+	if n := p.Count(ActionSet); n > 0 {
+		summary += fmt.Sprintf(", %d to set", n)
+	}
+	return summary + "."
+}
+
+// Lookup returns the current entries of a kind at a target, keyed by name.
+// Secret values cannot be read back from GitHub, so their values are empty.
+type Lookup func(target Target, kind Kind) (map[string]string, error)
+
+// Resolve returns a copy of the plan checked against the current state. Set changes
+// become creates or updates, while deletes of missing entries and updates that would
+// leave a variable's value unchanged are dropped. Updates that also change access are
+// kept, since the lookup only reports values. Each target and kind is looked up once.
+func (p *Plan) Resolve(lookup Lookup) (*Plan, error) {
+	type key struct {
+		target Target
+		kind   Kind
+	}
+	current := make(map[key]map[string]string)
+
+	resolved := &Plan{}
+	for _, c := range p.Changes {
+		k := key{c.Target, c.Kind}
+		entries, ok := current[k]
+		if !ok {
+			var err error
+			entries, err = lookup(c.Target, c.Kind)
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up %s entries in %s: %w", c.Kind, c.Target, err)
+			}
+			current[k] = entries
+		}
+
+		value, exists := entries[c.Name]
+		switch c.Action {
+		case ActionDelete:
+			if !exists {
+				continue
+			}
+		case ActionSet, ActionCreate, ActionUpdate:
+			if !exists {
+				c.Action = ActionCreate
+				break
+			}
+			if c.Kind == KindVariable && value == c.Value && c.Access == nil {
+				continue
+			}
+			c.Action = ActionUpdate
+		}
+		resolved.Add(c)
+	}
+	return resolved, nil
+}
+
+// WriteText writes a human-readable plan grouped by target
+func (p *Plan) WriteText(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+
+	var order []Target
+	byTarget := make(map[Target][]Change)
+	for _, c := range p.Changes {
+		if _, ok := byTarget[c.Target]; !ok {
+			order = append(order, c.Target)
+		}
+		byTarget[c.Target] = append(byTarget[c.Target], c)
+	}
+
+	for _, target := range order {
+		if _, err := fmt.Fprintf(w, "%s:\n", target); err != nil {
+			return err
+		}
+		for _, c := range byTarget[target] {
+			if _, err := fmt.Fprintf(w, "  %s %s %s\n", actionSymbol(c.Action), c.Kind, c.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintln(w, p.Summary())
+	return err
+}
+
+// WriteJSON writes the plan as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	out := *p
+	if out.Changes == nil {
+		out.Changes = []Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func actionSymbol(action Action) string {
+	switch action {
+	case ActionCreate:
+		return "+"
+	case ActionUpdate:
+		return "~"
+	case ActionSet:
+		return "*"
+	case ActionDelete:
+		return "-"
+	}
+	return "?"
+}
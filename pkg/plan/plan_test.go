@@ -0,0 +1,143 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		target Target
+		want   string
+	}{
+		{OrgTarget("myorg"), "org myorg"},
+		{RepoTarget("owner/repo"), "owner/repo"},
+		{EnvironmentTarget("owner/repo", "prod"), "owner/repo (environment prod)"},
+		{UserTarget(), "user"},
+	}
+
+	for _, tc := range tests {
+		if got := tc.target.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+
+	owner, name := EnvironmentTarget("owner/repo", "prod").SplitRepo()
+	if owner != "owner" || name != "repo" {
+		t.Errorf("SplitRepo() = %q, %q, want owner, repo", owner, name)
+	}
+}
+
+func TestPlanWriteText(t *testing.T) {
+	p := &Plan{}
+	p.Add(
+		Change{Target: OrgTarget("myorg"), Kind: KindSecret, Name: "API_KEY", Action: ActionCreate, Value: "hidden"},
+		Change{Target: RepoTarget("o/r"), Kind: KindVariable, Name: "LOG_LEVEL", Action: ActionUpdate},
+		Change{Target: OrgTarget("myorg"), Kind: KindDependabotSecret, Name: "NPM_TOKEN", Action: ActionDelete},
+	)
+
+	var buf bytes.Buffer
+	if err := p.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+
+	want := `org myorg:
+  + secret API_KEY
+  - dependabot NPM_TOKEN
+o/r:
+  ~ variable LOG_LEVEL
+Plan: 1 to create, 1 to update, 1 to delete.
+`
+	if buf.String() != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestPlanWriteJSON(t *testing.T) {
+	p := &Plan{}
+	p.Add(Change{Target: RepoTarget("o/r"), Kind: KindSecret, Name: "API_KEY", Action: ActionCreate, Value: "hidden"})
+
+	var buf bytes.Buffer
+	if err := p.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "hidden") {
+		t.Errorf("WriteJSON leaked secret value: %s", buf.String())
+	}
+
+	var decoded Plan
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to decode plan: %v", err)
+	}
+	if len(decoded.Changes) != 1 || decoded.Changes[0].Target != RepoTarget("o/r") {
+		t.Errorf("decoded plan = %+v, want one change for o/r", decoded)
+	}
+}
+
+func TestPlanEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	p := &Plan{}
+	if err := p.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	if buf.String() != "No changes.\n" {
+		t.Errorf("WriteText = %q, want %q", buf.String(), "No changes.\n")
+	}
+}
+
+func TestPlanResolve(t *testing.T) {
+	repo := RepoTarget("o/r")
+	env := EnvironmentTarget("o/r", "prod")
+
+	p := &Plan{}
+	p.Add(
+		Change{Target: repo, Kind: KindSecret, Name: "EXISTING", Action: ActionSet, Value: "v"},
+		Change{Target: repo, Kind: KindSecret, Name: "NEW", Action: ActionSet, Value: "v"},
+		Change{Target: repo, Kind: KindVariable, Name: "SAME", Action: ActionSet, Value: "a"},
+		Change{Target: repo, Kind: KindVariable, Name: "CHANGED", Action: ActionSet, Value: "b"},
+		Change{Target: OrgTarget("o"), Kind: KindVariable, Name: "SCOPED", Action: ActionSet, Value: "a", Access: &Access{Visibility: "private"}},
+		Change{Target: env, Kind: KindSecret, Name: "EXISTING", Action: ActionDelete},
+		Change{Target: env, Kind: KindSecret, Name: "MISSING", Action: ActionDelete},
+	)
+
+	lookups := 0
+	resolved, err := p.Resolve(func(target Target, kind Kind) (map[string]string, error) {
+		lookups++
+		switch {
+		case target == repo && kind == KindSecret:
+			return map[string]string{"EXISTING": ""}, nil
+		case target == repo && kind == KindVariable:
+			return map[string]string{"SAME": "a", "CHANGED": "old"}, nil
+		case target == env && kind == KindSecret:
+			return map[string]string{"EXISTING": ""}, nil
+		case target == OrgTarget("o") && kind == KindVariable:
+			return map[string]string{"SCOPED": "a"}, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	if lookups != 4 {
+		t.Errorf("Resolve made %d lookups, want 4", lookups)
+	}
+
+	var got []string
+	for _, c := range resolved.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"update secret EXISTING in o/r",
+		"create secret NEW in o/r",
+		"update variable CHANGED in o/r",
+		"update variable SCOPED in org o",
+		"delete secret EXISTING in o/r (environment prod)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Resolve =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
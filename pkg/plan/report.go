@@ -0,0 +1,131 @@
+package plan
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Report is a machine-readable record of an executed plan
+type Report struct {
+	Command    string        `json:"command,omitempty"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Summary    ReportSummary `json:"summary"`
+	Results    Results       `json:"results"`
+}
+
+// ReportSummary counts the outcomes recorded in a report
+type ReportSummary struct {
+	Targets   int `json:"targets"`
+	Changes   int `json:"changes"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// NewReport returns a report of the results of running the given command
+func NewReport(command string, results Results) *Report {
+	if results == nil {
+		results = Results{}
+	}
+	return &Report{
+		Command:    command,
+		FinishedAt: time.Now().UTC(),
+		Summary: ReportSummary{
+			Targets:   results.Targets(),
+			Changes:   len(results),
+			Succeeded: results.Count(StatusSucceeded),
+			Failed:    results.Count(StatusFailed),
+		},
+		Results: results,
+	}
+}
+
+// ReadReport decodes a report previously written with WriteJSON
+func ReadReport(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %w", err)
+	}
+	return &report, nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the report as JUnit XML with a test suite per target and a
+// test case per change, so CI systems can display which targets failed
+func (r *Report) WriteJUnit(w io.Writer) error {
+	out := junitTestSuites{
+		Name:     r.Command,
+		Tests:    r.Summary.Changes,
+		Failures: r.Summary.Failed,
+	}
+
+	suites := make(map[Target]int)
+	for _, result := range r.Results {
+		i, ok := suites[result.Target]
+		if !ok {
+			i = len(out.Suites)
+			suites[result.Target] = i
+			out.Suites = append(out.Suites, junitTestSuite{
+				Name:      result.Target.String(),
+				Timestamp: r.FinishedAt.Format(time.RFC3339),
+			})
+		}
+
+		suite := &out.Suites[i]
+		tc := junitTestCase{
+			ClassName: result.Target.String(),
+			Name:      fmt.Sprintf("%s %s %s", result.Action, result.Kind, result.Name),
+		}
+		if result.Status == StatusFailed {
+			tc.Failure = &junitFailure{Message: result.Error}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func testResults() Results {
+	return Results{
+		{Change: Change{Target: RepoTarget("o/a"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet, Value: "hidden"}, Status: StatusSucceeded},
+		{Change: Change{Target: RepoTarget("o/b"), Kind: KindSecret, Name: "API_KEY", Action: ActionSet, Value: "hidden"}, Status: StatusFailed, Error: "403 Forbidden"},
+		{Change: Change{Target: RepoTarget("o/b"), Kind: KindVariable, Name: "ENV", Action: ActionDelete}, Status: StatusSucceeded},
+	}
+}
+
+func TestReportJSONRoundTrip(t *testing.T) {
+	report := NewReport("gh secrets-manager secrets set", testResults())
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	if strings.Contains(buf.String(), "hidden") {
+		t.Errorf("WriteJSON leaked secret value: %s", buf.String())
+	}
+
+	decoded, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport returned error: %v", err)
+	}
+
+	want := ReportSummary{Targets: 2, Changes: 3, Succeeded: 2, Failed: 1}
+	if decoded.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", decoded.Summary, want)
+	}
+	failed := decoded.Results.Failed()
+	if len(failed) != 1 || failed[0].Target != RepoTarget("o/b") || failed[0].Error != "403 Forbidden" {
+		t.Errorf("failed results = %+v, want API_KEY in o/b", failed)
+	}
+}
+
+func TestReportJUnit(t *testing.T) {
+	report := NewReport("gh secrets-manager secrets set", testResults())
+
+	var buf bytes.Buffer
+	if err := report.WriteJUnit(&buf); err != nil {
+		t.Fatalf("WriteJUnit returned error: %v", err)
+	}
+
+	var decoded junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to decode JUnit report: %v\n%s", err, buf.String())
+	}
+
+	if decoded.Tests != 3 || decoded.Failures != 1 || len(decoded.Suites) != 2 {
+		t.Fatalf("decoded report = %+v, want 3 tests, 1 failure and 2 suites", decoded)
+	}
+	suite := decoded.Suites[1]
+	if suite.Name != "o/b" || suite.Tests != 2 || suite.Failures != 1 {
+		t.Errorf("suite = %+v, want o/b with 2 tests and 1 failure", suite)
+	}
+	if suite.Cases[0].Name != "set secret API_KEY" || suite.Cases[0].Failure == nil || suite.Cases[0].Failure.Message != "403 Forbidden" {
+		t.Errorf("case = %+v, want failed set secret API_KEY", suite.Cases[0])
+	}
+}
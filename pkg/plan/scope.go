@@ -0,0 +1,56 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseScope parses a scope given as org, owner/repo or owner/repo:environment.
+// With a repository, a bare name is one of its environments.
+func ParseScope(scope, repo string) (Target, error) {
+	name, environment, hasEnvironment := strings.Cut(scope, ":")
+	switch {
+	case hasEnvironment && (environment == "" || !strings.Contains(name, "/")):
+		return Target{}, fmt.Errorf("invalid scope %q, expected owner/repo:environment", scope)
+	case hasEnvironment:
+		return EnvironmentTarget(name, environment), nil
+	case strings.Count(scope, "/") == 1 && !strings.HasPrefix(scope, "/") && !strings.HasSuffix(scope, "/"):
+		return RepoTarget(scope), nil
+	case strings.Contains(scope, "/"):
+		return Target{}, fmt.Errorf("invalid scope %q, expected org, owner/repo or owner/repo:environment", scope)
+	case repo != "":
+		return EnvironmentTarget(repo, scope), nil
+	default:
+		return OrgTarget(scope), nil
+	}
+}
+
+// ScopeKinds returns the kinds named by a --kind flag, or every kind both scopes can
+// hold when none are named. Environments have no Dependabot secrets.
+func ScopeKinds(names []string, from, to Target) ([]Kind, error) {
+	environment := from.IsEnvironment() || to.IsEnvironment()
+	if len(names) == 0 {
+		if environment {
+			return []Kind{KindSecret, KindVariable}, nil
+		}
+		return []Kind{KindSecret, KindVariable, KindDependabotSecret}, nil
+	}
+
+	var kinds []Kind
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "secret", "secrets":
+			kinds = append(kinds, KindSecret)
+		case "variable", "variables":
+			kinds = append(kinds, KindVariable)
+		case "dependabot":
+			if environment {
+				return nil, fmt.Errorf("environments do not have Dependabot secrets")
+			}
+			kinds = append(kinds, KindDependabotSecret)
+		default:
+			return nil, fmt.Errorf("unsupported kind %q, expected secrets, variables or dependabot", name)
+		}
+	}
+	return kinds, nil
+}
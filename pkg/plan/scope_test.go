@@ -0,0 +1,71 @@
+package plan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		repo  string
+		want  Target
+	}{
+		{"myorg", "", OrgTarget("myorg")},
+		{"owner/repo", "", RepoTarget("owner/repo")},
+		{"owner/repo:staging", "", EnvironmentTarget("owner/repo", "staging")},
+		{"staging", "owner/repo", EnvironmentTarget("owner/repo", "staging")},
+		{"owner/other", "owner/repo", RepoTarget("owner/other")},
+		{"owner/repo:prod eu", "owner/other", EnvironmentTarget("owner/repo", "prod eu")},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseScope(tc.scope, tc.repo)
+		if err != nil {
+			t.Errorf("ParseScope(%q, %q) returned error: %v", tc.scope, tc.repo, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseScope(%q, %q) = %v, want %v", tc.scope, tc.repo, got, tc.want)
+		}
+	}
+
+	for _, scope := range []string{"owner/repo:", "myorg:staging", "a/b/c", "/repo", "owner/"} {
+		if _, err := ParseScope(scope, ""); err == nil {
+			t.Errorf("ParseScope(%q) returned nil error", scope)
+		}
+	}
+}
+
+func TestScopeKinds(t *testing.T) {
+	org, repo := OrgTarget("myorg"), RepoTarget("owner/repo")
+	env := EnvironmentTarget("owner/repo", "prod")
+
+	tests := []struct {
+		names    []string
+		from, to Target
+		want     []Kind
+		err      string
+	}{
+		{nil, org, repo, []Kind{KindSecret, KindVariable, KindDependabotSecret}, ""},
+		{nil, repo, env, []Kind{KindSecret, KindVariable}, ""},
+		{[]string{"Variables", "secret"}, org, repo, []Kind{KindVariable, KindSecret}, ""},
+		{[]string{"dependabot"}, org, repo, []Kind{KindDependabotSecret}, ""},
+		{[]string{"dependabot"}, env, repo, nil, "Dependabot"},
+		{[]string{"codespaces"}, org, repo, nil, "unsupported kind"},
+	}
+
+	for _, tc := range tests {
+		got, err := ScopeKinds(tc.names, tc.from, tc.to)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("ScopeKinds(%v) error = %v, want %q", tc.names, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ScopeKinds(%v, %v, %v) = %v, %v, want %v", tc.names, tc.from, tc.to, got, err, tc.want)
+		}
+	}
+}
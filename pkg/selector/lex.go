@@ -0,0 +1,91 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenEqual
+	tokenNotEqual
+	tokenColon
+	tokenComma
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits an expression into tokens, ending with tokenEOF
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("expected %c%c at position %d of selector", r, r, i+1)
+			}
+			kind := tokenAnd
+			if r == '|' {
+				kind = tokenOr
+			}
+			tokens = append(tokens, token{kind: kind, text: string(runes[i : i+2]), pos: i})
+			i += 2
+		case r == '!':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokenNotEqual, text: "!=", pos: i})
+				i += 2
+			} else {
+				tokens = append(tokens, token{kind: tokenNot, text: "!", pos: i})
+				i++
+			}
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d of selector", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[i+1 : end]), pos: i})
+			i = end + 1
+		case strings.ContainsRune("=:,()", r):
+			kinds := map[rune]tokenKind{'=': tokenEqual, ':': tokenColon, ',': tokenComma, '(': tokenLParen, ')': tokenRParen}
+			tokens = append(tokens, token{kind: kinds[r], text: string(r), pos: i})
+			i++
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d of selector", r, i+1)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-./*?", r)
+}
@@ -0,0 +1,326 @@
+// Package selector parses and evaluates repository selection expressions such as
+//
+//	team=backend && tier in (1,2) && !archived && topic:payments && visibility=private
+//
+// Terms compare a field with = or != or test membership with in (...). The fields
+// name and visibility come from the repository; any other field names a custom
+// property, which can also be written property.NAME when it clashes with a built-in
+// field. topic:NAME tests for a topic, and archived and fork are true for archived
+// and forked repositories; they take no value, so archived=true is an error. Terms
+// combine with !, && and || and can be grouped with parentheses; && binds tighter
+// than ||. Values containing spaces or operators are quoted with single or double
+// quotes.
+package selector
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Repository is the data a selector is evaluated against. Properties maps custom
+// property names to their values; multi-select properties have several.
+type Repository struct {
+	Name       string
+	Visibility string
+	Archived   bool
+	Fork       bool
+	Topics     []string
+	Properties map[string][]string
+}
+
+// Selector is a parsed selection expression
+type Selector struct {
+	expr string
+	root node
+}
+
+// Parse parses a selection expression
+func Parse(expr string) (*Selector, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return &Selector{expr: expr, root: root}, nil
+}
+
+// Match reports whether the repository is selected
+func (s *Selector) Match(repo *Repository) bool {
+	return s.root.match(repo)
+}
+
+// UsesProperties reports whether evaluating the selector needs custom property values
+func (s *Selector) UsesProperties() bool {
+	return s.root.uses(func(n node) bool {
+		field, ok := n.(*compareNode)
+		return ok && field.property
+	})
+}
+
+// UsesArchived reports whether the selector tests whether repositories are archived
+func (s *Selector) UsesArchived() bool {
+	return s.root.uses(func(n node) bool {
+		flag, ok := n.(*flagNode)
+		return ok && flag.name == "archived"
+	})
+}
+
+func (s *Selector) String() string {
+	return s.expr
+}
+
+type node interface {
+	match(repo *Repository) bool
+	// uses reports whether f holds for the node or any node below it
+	uses(f func(node) bool) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) match(repo *Repository) bool { return n.left.match(repo) && n.right.match(repo) }
+func (n *andNode) uses(f func(node) bool) bool { return f(n) || n.left.uses(f) || n.right.uses(f) }
+
+type orNode struct{ left, right node }
+
+func (n *orNode) match(repo *Repository) bool { return n.left.match(repo) || n.right.match(repo) }
+func (n *orNode) uses(f func(node) bool) bool { return f(n) || n.left.uses(f) || n.right.uses(f) }
+
+type notNode struct{ operand node }
+
+func (n *notNode) match(repo *Repository) bool { return !n.operand.match(repo) }
+func (n *notNode) uses(f func(node) bool) bool { return f(n) || n.operand.uses(f) }
+
+// flagNode is a bare boolean field: archived or fork
+type flagNode struct{ name string }
+
+func (n *flagNode) match(repo *Repository) bool {
+	if n.name == "archived" {
+		return repo.Archived
+	}
+	return repo.Fork
+}
+func (n *flagNode) uses(f func(node) bool) bool { return f(n) }
+
+type topicNode struct{ topic string }
+
+func (n *topicNode) match(repo *Repository) bool {
+	return slices.ContainsFunc(repo.Topics, func(topic string) bool { return strings.EqualFold(topic, n.topic) })
+}
+func (n *topicNode) uses(f func(node) bool) bool { return f(n) }
+
+// compareNode matches when the field has one of the values, or, when negated, none
+// of them. Repository names match glob patterns.
+type compareNode struct {
+	field    string
+	property bool
+	values   []string
+	negate   bool
+}
+
+func (n *compareNode) match(repo *Repository) bool {
+	var actual []string
+	switch {
+	case n.property:
+		actual = repo.Properties[n.field]
+	case n.field == "name":
+		actual = []string{repo.Name}
+	case n.field == "visibility":
+		actual = []string{strings.ToLower(repo.Visibility)}
+	}
+
+	found := false
+	for _, want := range n.values {
+		for _, have := range actual {
+			if n.equal(want, have) {
+				found = true
+			}
+		}
+	}
+	return found != n.negate
+}
+
+func (n *compareNode) equal(want, have string) bool {
+	switch {
+	case n.property:
+		return want == have
+	case n.field == "name":
+		ok, _ := path.Match(want, have)
+		return ok
+	default:
+		return strings.EqualFold(want, have)
+	}
+}
+
+func (n *compareNode) uses(f func(node) bool) bool { return f(n) }
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("selector ends unexpectedly")
+	}
+	return fmt.Errorf("unexpected %q at position %d of selector", tok.text, tok.pos+1)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch tok := p.peek(); tok.kind {
+	case tokenNot:
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+	case tokenLParen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, p.unexpected(tok)
+		}
+		return expr, nil
+	case tokenWord:
+		return p.parseTerm()
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
+
+func (p *parser) parseTerm() (node, error) {
+	field := p.next()
+
+	switch op := p.peek(); {
+	case op.kind == tokenColon:
+		p.next()
+		if field.text != "topic" {
+			return nil, fmt.Errorf("unknown qualifier %q at position %d of selector, expected topic", field.text, field.pos+1)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &topicNode{topic: value}, nil
+
+	case (op.kind == tokenEqual || op.kind == tokenNotEqual || op.kind == tokenWord && op.text == "in") && isFlag(field.text):
+		return nil, fmt.Errorf("%s at position %d of selector is a flag and takes no value: write %s or !%s", field.text, field.pos+1, field.text, field.text)
+
+	case op.kind == tokenEqual || op.kind == tokenNotEqual:
+		p.next()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return newCompareNode(field.text, []string{value}, op.kind == tokenNotEqual), nil
+
+	case op.kind == tokenWord && op.text == "in":
+		p.next()
+		if tok := p.next(); tok.kind != tokenLParen {
+			return nil, p.unexpected(tok)
+		}
+		var values []string
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if tok := p.next(); tok.kind == tokenRParen {
+				break
+			} else if tok.kind != tokenComma {
+				return nil, p.unexpected(tok)
+			}
+		}
+		return newCompareNode(field.text, values, false), nil
+	}
+
+	if isFlag(field.text) {
+		return &flagNode{name: field.text}, nil
+	}
+	return nil, fmt.Errorf("expected =, !=, in or : after %q at position %d of selector", field.text, field.pos+1)
+}
+
+// isFlag reports whether a field is a bare boolean field. Comparing one with a value
+// would otherwise read as a custom property of the same name that never matches;
+// property.archived names such a property.
+func isFlag(field string) bool {
+	return field == "archived" || field == "fork"
+}
+
+func (p *parser) parseValue() (string, error) {
+	tok := p.next()
+	if tok.kind != tokenWord && tok.kind != tokenString {
+		return "", p.unexpected(tok)
+	}
+	return tok.text, nil
+}
+
+func newCompareNode(field string, values []string, negate bool) *compareNode {
+	n := &compareNode{field: field, values: values, negate: negate}
+	switch {
+	case strings.HasPrefix(field, "property."):
+		n.field = strings.TrimPrefix(field, "property.")
+		n.property = true
+	case field != "name" && field != "visibility":
+		n.property = true
+	}
+	return n
+}
@@ -0,0 +1,130 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+)
+
+var repos = []*Repository{
+	{
+		Name:       "payments-api",
+		Visibility: "private",
+		Topics:     []string{"payments", "go"},
+		Properties: map[string][]string{"team": {"backend"}, "tier": {"1"}, "regions": {"eu", "us"}},
+	},
+	{
+		Name:       "payments-legacy",
+		Visibility: "private",
+		Archived:   true,
+		Topics:     []string{"payments"},
+		Properties: map[string][]string{"team": {"backend"}, "tier": {"2"}},
+	},
+	{
+		Name:       "website",
+		Visibility: "public",
+		Fork:       true,
+		Properties: map[string][]string{"team": {"frontend"}, "tier": {"3"}, "owner": {"Web Team"}},
+	},
+	{
+		Name:       "scratch",
+		Visibility: "internal",
+	},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"team=backend && tier in (1,2) && !archived && topic:payments && visibility=private", []string{"payments-api"}},
+		{"team=backend", []string{"payments-api", "payments-legacy"}},
+		{"team!=backend", []string{"website", "scratch"}},
+		{"tier in (2, 3)", []string{"payments-legacy", "website"}},
+		{"regions=us", []string{"payments-api"}},
+		{"archived || fork", []string{"payments-legacy", "website"}},
+		{"!(archived || fork)", []string{"payments-api", "scratch"}},
+		{"team=frontend || team=backend && archived", []string{"payments-legacy", "website"}},
+		{"(team=frontend || team=backend) && !archived", []string{"payments-api", "website"}},
+		{"topic:GO", []string{"payments-api"}},
+		{"visibility=PUBLIC", []string{"website"}},
+		{"name=payments-*", []string{"payments-api", "payments-legacy"}},
+		{`owner='Web Team' || owner="a && b"`, []string{"website"}},
+		{`property.team=frontend`, []string{"website"}},
+		{"team=Backend", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			var got []string
+			for _, repo := range repos {
+				if s.Match(repo) {
+					got = append(got, repo.Name)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("matched %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "ends unexpectedly"},
+		{"team", `expected =, !=, in or : after "team"`},
+		{"team=", "ends unexpectedly"},
+		{"team=backend &", "expected &&"},
+		{"team=backend && && tier=1", `unexpected "&&" at position 17`},
+		{"tier in 1", `unexpected "1"`},
+		{"tier in (1,", "ends unexpectedly"},
+		{"(team=backend", "ends unexpectedly"},
+		{"team=backend)", `unexpected ")"`},
+		{"label:bug", `unknown qualifier "label"`},
+		{`team="backend`, "unterminated string"},
+		{"team=back$end", `unexpected '$'`},
+		{"archived=true", "archived at position 1 of selector is a flag and takes no value: write archived or !archived"},
+		{"team=a && fork!=false", "fork at position 11 of selector is a flag"},
+		{"fork in (true)", "write fork or !fork"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Parse error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestUses(t *testing.T) {
+	tests := []struct {
+		expr       string
+		properties bool
+		archived   bool
+	}{
+		{"visibility=private && topic:go", false, false},
+		{"name=api || !(fork || team=backend)", true, false},
+		{"!archived", false, true},
+		{"property.archived=yes", true, false},
+		{"property.fork in (yes, no) && fork", true, false},
+	}
+
+	for _, tc := range tests {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tc.expr, err)
+		}
+		if s.UsesProperties() != tc.properties || s.UsesArchived() != tc.archived {
+			t.Errorf("Parse(%q) uses properties %v and archived %v, want %v and %v",
+				tc.expr, s.UsesProperties(), s.UsesArchived(), tc.properties, tc.archived)
+		}
+	}
+}